@@ -0,0 +1,257 @@
+// Command resource-estimator builds estimator inputs from an inventory of repositories and
+// calculates estimates from them.
+//
+// Usage:
+//
+//	resource-estimator scan [-o input.json] <dir>
+//	resource-estimator import -source github|gitlab|bitbucket [-o input.json] <file>...
+//	resource-estimator usage [-o input.json] <pings.json>
+//	resource-estimator calculate [-format markdown|helm|docker-compose|terraform|terraform-json|hpa|pdb|quota] [-environment production|staging] <input.json>
+//	resource-estimator cluster [-format helm] [-o dir] <cluster.json>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sourcegraph/resource-estimator/internal/inventory"
+	"github.com/sourcegraph/resource-estimator/internal/scaling"
+)
+
+var commands = map[string]func(args []string) error{
+	"scan":      scan,
+	"import":    importRepositories,
+	"usage":     importUsage,
+	"calculate": calculate,
+	"cluster":   cluster,
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: resource-estimator <scan|import|usage|calculate|cluster> [flags] <args>")
+		os.Exit(2)
+	}
+	if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "err: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func scan(args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	output := fs.String("o", "input.json", "path of the estimator input file to write")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: resource-estimator scan [-o input.json] <dir>")
+		fmt.Fprintln(fs.Output(), "\nScans a directory of bare repositories or working clones and writes an estimator input file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	repos, unreadable, err := inventory.Scan(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	input := inventory.Summarize(repos)
+	if err := input.WriteFile(*output); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "found %v repositories (%vg in total, largest %vg), wrote %v\n", input.Repositories, input.TotalRepoSize, input.LargestRepoSize, *output)
+	for _, r := range repos {
+		if r.Missing {
+			fmt.Fprintf(os.Stderr, "could not measure %v, counted as a repository of average size\n", r.Name)
+		}
+	}
+	for _, name := range unreadable {
+		fmt.Fprintf(os.Stderr, "warning: could not read %v, its repositories are not counted\n", name)
+	}
+	return nil
+}
+
+func importRepositories(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	source := fs.String("source", "", "code host the files were exported from: "+strings.Join(inventory.Sources, ", "))
+	output := fs.String("o", "input.json", "path of the estimator input file to write")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: resource-estimator import -source github [-o input.json] <file>...")
+		fmt.Fprintln(fs.Output(), "\nImports repository lists exported from a code host API and writes an estimator input file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 || *source == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var repos []inventory.Repository
+	for _, name := range fs.Args() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		imported, err := inventory.Import(*source, data)
+		if err != nil {
+			return fmt.Errorf("%v: %w", name, err)
+		}
+		repos = append(repos, imported...)
+	}
+	repos = inventory.Dedupe(repos)
+	input := inventory.Summarize(repos)
+	if err := input.WriteFile(*output); err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stderr, inventory.ImportSummary(repos))
+	fmt.Fprintf(os.Stderr, "\nwrote %v\n", *output)
+	return nil
+}
+
+func importUsage(args []string) error {
+	fs := flag.NewFlagSet("usage", flag.ExitOnError)
+	output := fs.String("o", "input.json", "path of the estimator input file to write")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: resource-estimator usage [-o input.json] <pings.json>")
+		fmt.Fprintln(fs.Output(), "\nImports the usage statistics of an existing instance and writes an estimator input file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	input, err := inventory.ImportUsage(data)
+	if err != nil {
+		return err
+	}
+	if err := input.WriteFile(*output); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "found %v active users (%v%% daily) and %v repositories, wrote %v\n", input.Users, input.EngagementRate, input.Repositories, *output)
+	return nil
+}
+
+func calculate(args []string) error {
+	fs := flag.NewFlagSet("calculate", flag.ExitOnError)
+	format := fs.String("format", "markdown", "output format: markdown, helm, docker-compose, terraform, terraform-json, hpa, pdb or quota")
+	environment := fs.String("environment", "production", "environment to export: production, or staging derived from it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: resource-estimator calculate [-format markdown] [-environment production] <input.json>")
+		fmt.Fprintln(fs.Output(), "\nCalculates the estimate for an estimator input file.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	e, err := inventory.ReadEstimate(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if e.DeploymentType == "" {
+		e.DeploymentType = "kubernetes"
+	}
+	e = e.Calculate()
+	switch *environment {
+	case "production":
+	case "staging":
+		e = e.Staging()
+	default:
+		return fmt.Errorf("unknown environment %q", *environment)
+	}
+	out, err := export(e, *format)
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
+
+func cluster(args []string) error {
+	fs := flag.NewFlagSet("cluster", flag.ExitOnError)
+	format := fs.String("format", "helm", "format of the export of each instance: markdown, helm, docker-compose, terraform, terraform-json, hpa, pdb or quota")
+	output := fs.String("o", "", "directory to write the export of each instance to, named after its namespace")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: resource-estimator cluster [-format helm] [-o dir] <cluster.json>")
+		fmt.Fprintln(fs.Output(), "\nCalculates the instances sharing a Kubernetes cluster and prints the cluster report.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if _, ok := exportExtensions[*format]; !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	c, err := inventory.ReadCluster(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	report := c.Calculate()
+	if *output != "" {
+		if err := os.MkdirAll(*output, 0o755); err != nil {
+			return err
+		}
+		for _, instance := range report.Instances {
+			out, err := export(instance.Estimate, *format)
+			if err != nil {
+				return err
+			}
+			name := filepath.Join(*output, instance.Namespace+exportExtensions[*format])
+			if err := os.WriteFile(name, []byte(out), 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "wrote %v\n", name)
+		}
+	}
+	fmt.Print(string(report.MarkdownExport()))
+	return nil
+}
+
+// exportExtensions are the file extensions of the export formats.
+var exportExtensions = map[string]string{
+	"markdown":       ".md",
+	"helm":           ".yaml",
+	"docker-compose": ".docker-compose.yaml",
+	"terraform":      ".tfvars",
+	"terraform-json": ".tfvars.json",
+	"hpa":            ".hpa.yaml",
+	"pdb":            ".pdb.yaml",
+	"quota":          ".quota.yaml",
+}
+
+func export(e *scaling.Estimate, format string) (string, error) {
+	switch format {
+	case "markdown":
+		return string(e.MarkdownExport()), nil
+	case "helm":
+		return e.HelmExport(), nil
+	case "docker-compose":
+		return e.DockerExport(), nil
+	case "terraform":
+		return e.TerraformExport(), nil
+	case "terraform-json":
+		return e.TerraformJSONExport(), nil
+	case "hpa":
+		return e.HPAExport(), nil
+	case "pdb":
+		return e.PDBExport(), nil
+	case "quota":
+		return e.QuotaExport(), nil
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/sourcegraph/resource-estimator
 go 1.18
 
 require (
+	github.com/ghodss/yaml v1.0.0
 	github.com/hajimehoshi/wasmserve v1.2.1
 	github.com/hexops/autogold v1.3.0
 	github.com/hexops/vecty v0.6.0
@@ -11,7 +12,6 @@ require (
 )
 
 require (
-	github.com/ghodss/yaml v1.0.0 // indirect
 	github.com/google/go-cmp v0.5.4 // indirect
 	github.com/hexops/gotextdiff v1.0.3 // indirect
 	github.com/hexops/valast v1.4.0 // indirect
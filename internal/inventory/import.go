@@ -0,0 +1,178 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sources are the code hosts whose repository lists can be imported.
+var Sources = []string{"github", "gitlab", "bitbucket"}
+
+// Import reads a repository list exported from a code host API:
+//
+//   - github: the output of `gh repo list --json nameWithOwner,diskUsage`, or the REST API's list
+//     of repositories. Sizes are in KB.
+//   - gitlab: the projects API with `statistics=true`. Sizes are in bytes.
+//   - bitbucket: the Bitbucket Cloud repositories API, either a page with `values` or a list of
+//     repositories. Sizes are in bytes. Bitbucket Server does not report sizes.
+//
+// Repositories without size data are returned with Missing set.
+func Import(source string, data []byte) ([]Repository, error) {
+	switch source {
+	case "github":
+		return importGitHub(data)
+	case "gitlab":
+		return importGitLab(data)
+	case "bitbucket":
+		return importBitbucket(data)
+	default:
+		return nil, fmt.Errorf("unknown source %q, expected one of %v", source, strings.Join(Sources, ", "))
+	}
+}
+
+func importGitHub(data []byte) ([]Repository, error) {
+	var list []struct {
+		NameWithOwner string `json:"nameWithOwner"`
+		FullName      string `json:"full_name"`
+		DiskUsage     *int64 `json:"diskUsage"`
+		Size          *int64 `json:"size"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var repos []Repository
+	for _, r := range list {
+		repo := Repository{Name: firstNonEmpty(r.NameWithOwner, r.FullName)}
+		switch {
+		case r.DiskUsage != nil:
+			repo.Size = *r.DiskUsage * 1024
+		case r.Size != nil:
+			repo.Size = *r.Size * 1024
+		default:
+			repo.Missing = true
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+func importGitLab(data []byte) ([]Repository, error) {
+	var list []struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+		Statistics        *struct {
+			RepositorySize *int64 `json:"repository_size"`
+		} `json:"statistics"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var repos []Repository
+	for _, r := range list {
+		repo := Repository{Name: r.PathWithNamespace}
+		if r.Statistics != nil && r.Statistics.RepositorySize != nil {
+			repo.Size = *r.Statistics.RepositorySize
+		} else {
+			repo.Missing = true
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+type bitbucketRepository struct {
+	FullName string `json:"full_name"`
+	Slug     string `json:"slug"`
+	Project  struct {
+		Key string `json:"key"`
+	} `json:"project"`
+	Size *int64 `json:"size"`
+}
+
+func importBitbucket(data []byte) ([]Repository, error) {
+	var list []bitbucketRepository
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		var page struct {
+			Values []bitbucketRepository `json:"values"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, err
+		}
+		list = page.Values
+	} else if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	var repos []Repository
+	for _, r := range list {
+		name := r.FullName
+		if name == "" {
+			name = strings.TrimPrefix(r.Project.Key+"/"+r.Slug, "/")
+		}
+		repo := Repository{Name: name}
+		if r.Size != nil {
+			repo.Size = *r.Size
+		} else {
+			repo.Missing = true
+		}
+		repos = append(repos, repo)
+	}
+	return repos, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Dedupe removes repositories listed more than once, such as when pages of an export overlap.
+func Dedupe(repos []Repository) []Repository {
+	seen := map[string]struct{}{}
+	var deduped []Repository
+	for _, r := range repos {
+		if _, ok := seen[r.Name]; ok && r.Name != "" {
+			continue
+		}
+		seen[r.Name] = struct{}{}
+		deduped = append(deduped, r)
+	}
+	return deduped
+}
+
+// ImportSummary describes the imported repositories: the largest ones, and the ones without size
+// data.
+func ImportSummary(repos []Repository) string {
+	var known, missing []Repository
+	for _, r := range repos {
+		if r.Missing {
+			missing = append(missing, r)
+		} else {
+			known = append(known, r)
+		}
+	}
+	sort.SliceStable(known, func(i, j int) bool { return known[i].Size > known[j].Size })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Imported %v repositories, %v with size data.\n", len(repos), len(known))
+	if len(known) > 0 {
+		fmt.Fprintf(&buf, "\nLargest repositories:\n")
+		for i := 0; i < topRepositories && i < len(known); i++ {
+			fmt.Fprintf(&buf, "  %-50v %8.2fg\n", known[i].Name, float64(known[i].Size)/bytesPerGB)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&buf, "\nRepositories without size data, assumed to be of average size:\n")
+		for i := 0; i < topRepositories && i < len(missing); i++ {
+			fmt.Fprintf(&buf, "  %v\n", missing[i].Name)
+		}
+		if len(missing) > topRepositories {
+			fmt.Fprintf(&buf, "  ... and %v more\n", len(missing)-topRepositories)
+		}
+	}
+	return buf.String()
+}
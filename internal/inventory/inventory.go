@@ -0,0 +1,138 @@
+// Package inventory builds estimator inputs from an inventory of repositories.
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/sourcegraph/resource-estimator/internal/scaling"
+)
+
+const bytesPerGB = 1 << 30
+
+// topRepositories is the number of largest repositories listed individually in the size
+// distribution.
+const topRepositories = 10
+
+// bucketBounds are the upper bounds in GB of the buckets of the size distribution. Anything
+// larger is in the last bucket, which is bounded by the largest repository.
+var bucketBounds = []float64{0.01, 0.1, 1, 10, 100}
+
+// Repository is a repository and its size on disk.
+type Repository struct {
+	Name string
+	Size int64 // in bytes
+	// Missing is true when the size of the repository is unknown.
+	Missing bool
+}
+
+// Input is an estimator input file. Its fields are named after the fields of scaling.Estimate,
+// so the file can be decoded into a scaling.Estimate and calculated directly.
+type Input struct {
+	DeploymentType       string                        `json:",omitempty"`
+	Users                int                           `json:",omitempty"`
+	EngagementRate       int                           `json:",omitempty"`
+	CodeInsight          string                        `json:",omitempty"`
+	LargestIndexSize     int                           `json:",omitempty"`
+	Repositories         int                           `json:",omitempty"`
+	TotalRepoSize        int                           `json:",omitempty"`
+	LargestRepoSize      int                           `json:",omitempty"`
+	RepoSizeDistribution *scaling.RepoSizeDistribution `json:",omitempty"`
+}
+
+// Summarize returns the estimator inputs for the given repositories. Repositories without size
+// data are counted, and assumed to be of the average size of the others.
+func Summarize(all []Repository) Input {
+	var (
+		repos []Repository
+		total int64
+	)
+	for _, r := range all {
+		if !r.Missing {
+			repos = append(repos, r)
+			total += r.Size
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].Size > repos[j].Size })
+
+	totalGB := float64(total) / bytesPerGB
+	if missing := len(all) - len(repos); missing > 0 && len(repos) > 0 {
+		totalGB += totalGB / float64(len(repos)) * float64(missing)
+	}
+	input := Input{
+		Repositories:         len(all),
+		TotalRepoSize:        int(math.Ceil(totalGB)),
+		RepoSizeDistribution: &scaling.RepoSizeDistribution{},
+	}
+	if len(repos) == 0 {
+		return input
+	}
+	input.LargestRepoSize = int(math.Ceil(float64(repos[0].Size) / bytesPerGB))
+
+	d := input.RepoSizeDistribution
+	for i, r := range repos {
+		size := float64(r.Size) / bytesPerGB
+		if i < topRepositories {
+			d.TopRepositories = append(d.TopRepositories, size)
+			continue
+		}
+		bucket := sort.SearchFloat64s(bucketBounds, size)
+		for len(d.Buckets) <= bucket {
+			max := float64(input.LargestRepoSize)
+			if len(d.Buckets) < len(bucketBounds) {
+				max = bucketBounds[len(d.Buckets)]
+			}
+			d.Buckets = append(d.Buckets, scaling.RepoSizeBucket{MaxSizeGB: max})
+		}
+		d.Buckets[bucket].Count++
+	}
+	return input
+}
+
+// WriteFile writes the input file as JSON.
+func (i Input) WriteFile(name string) error {
+	data, err := json.MarshalIndent(i, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
+
+// ReadEstimate reads an input file written by WriteFile, or any JSON file with fields named after
+// the fields of scaling.Estimate.
+func ReadEstimate(name string) (*scaling.Estimate, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var e scaling.Estimate
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ReadCluster reads a cluster file: a list of instances, each with the namespace it is deployed in
+// and its estimator inputs.
+func ReadCluster(name string) (*scaling.Cluster, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var c scaling.Cluster
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	for i, instance := range c.Instances {
+		if instance.Namespace == "" {
+			return nil, fmt.Errorf("instance %v has no namespace", i+1)
+		}
+		if instance.Estimate == nil {
+			return nil, fmt.Errorf("instance %v has no estimate", instance.Namespace)
+		}
+	}
+	return &c, nil
+}
@@ -0,0 +1,167 @@
+package inventory_test
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hexops/autogold"
+	"github.com/sourcegraph/resource-estimator/internal/inventory"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	// A bare repository, a working clone, and a directory which is not a repository.
+	writeRepo(t, filepath.Join(dir, "github.com", "org", "bare.git"), 3000)
+	writeRepo(t, filepath.Join(dir, "github.com", "org", "clone", ".git"), 1000)
+	writeFile(t, filepath.Join(dir, "github.com", "org", "clone", "README.md"), 5000)
+	writeFile(t, filepath.Join(dir, "notes", "README.md"), 100)
+
+	repos, unreadable, err := inventory.Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unreadable) > 0 {
+		t.Fatalf("unreadable directories: %v", unreadable)
+	}
+	autogold.Equal(t, repos)
+}
+
+type scanResult struct {
+	Repositories []inventory.Repository
+	Unreadable   []string
+}
+
+func TestScanUnreadable(t *testing.T) {
+	// An organization which cannot be listed, and a repository whose objects cannot be measured.
+	fsys := unreadableFS{MapFS: fstest.MapFS{}, unreadable: map[string]bool{
+		"github.com/private":                true,
+		"github.com/org/broken.git/objects": true,
+	}}
+	mapRepo(fsys.MapFS, "github.com/org/bare.git", 3000)
+	mapRepo(fsys.MapFS, "github.com/org/broken.git", 2000)
+	mapRepo(fsys.MapFS, "github.com/private/secret.git", 1000)
+
+	repos, unreadable, err := inventory.ScanFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	autogold.Equal(t, scanResult{Repositories: repos, Unreadable: unreadable})
+}
+
+// unreadableFS is a file system whose unreadable directories cannot be listed.
+type unreadableFS struct {
+	fstest.MapFS
+	unreadable map[string]bool
+}
+
+func (f unreadableFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.unreadable[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+func mapRepo(fsys fstest.MapFS, dir string, size int) {
+	fsys[path.Join(dir, "HEAD")] = &fstest.MapFile{Data: make([]byte, 20)}
+	fsys[path.Join(dir, "refs", "heads", "main")] = &fstest.MapFile{Data: make([]byte, 40)}
+	fsys[path.Join(dir, "objects", "pack", "pack-1.pack")] = &fstest.MapFile{Data: make([]byte, size)}
+}
+
+func TestSummarize(t *testing.T) {
+	var repos []inventory.Repository
+	for i := 0; i < 100; i++ {
+		repos = append(repos, inventory.Repository{Name: "small", Size: 5 << 20})
+	}
+	for i := 0; i < 20; i++ {
+		repos = append(repos, inventory.Repository{Name: "medium", Size: 512 << 20})
+	}
+	for i := 0; i < 12; i++ {
+		repos = append(repos, inventory.Repository{Name: "large", Size: int64(i+3) << 30})
+	}
+	autogold.Equal(t, inventory.Summarize(repos))
+}
+
+func writeRepo(t *testing.T, dir string, size int) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "HEAD"), 20)
+	writeFile(t, filepath.Join(dir, "refs", "heads", "main"), 40)
+	writeFile(t, filepath.Join(dir, "objects", "pack", "pack-1.pack"), size)
+}
+
+func writeFile(t *testing.T, name string, size int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type importResult struct {
+	Summary string
+	Input   inventory.Input
+}
+
+func TestImport(t *testing.T) {
+	cases := []struct {
+		Name, Source, Data string
+	}{{
+		Name:   "github",
+		Source: "github",
+		Data:   `[{"nameWithOwner": "org/a", "diskUsage": 2097152}, {"nameWithOwner": "org/b", "diskUsage": 512}, {"nameWithOwner": "org/c"}]`,
+	}, {
+		Name:   "github-rest",
+		Source: "github",
+		Data:   `[{"full_name": "org/a", "size": 1048576}]`,
+	}, {
+		Name:   "gitlab",
+		Source: "gitlab",
+		Data:   `[{"path_with_namespace": "group/a", "statistics": {"repository_size": 3221225472}}, {"path_with_namespace": "group/b"}]`,
+	}, {
+		Name:   "bitbucket",
+		Source: "bitbucket",
+		Data:   `{"values": [{"full_name": "team/a", "size": 1073741824}, {"slug": "b", "project": {"key": "PRJ"}}]}`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repos, err := inventory.Import(tc.Source, []byte(tc.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			autogold.Equal(t, importResult{Summary: inventory.ImportSummary(repos), Input: inventory.Summarize(repos)})
+		})
+	}
+}
+
+func TestImportUsage(t *testing.T) {
+	cases := []struct {
+		Name, Data string
+	}{{
+		Name: "pings",
+		Data: `{
+			"totalUsers": 1200,
+			"usageStatistics": {"DAUs": [{"UserCount": 240}, {"UserCount": 200}], "MAUs": [{"UserCount": 800}]},
+			"repositories": {"count": 5000, "totalSizeBytes": 161061273600},
+			"repositorySizeHistogram": [{"gte": 0, "lt": 1073741824, "count": 4990}, {"gte": 1073741824, "count": 10}],
+			"codeInsightsCriticalTelemetry": {"TotalInsights": 0},
+			"newCodeIntelUsage": {"numRepositoriesWithUploadRecords": 12}
+		}`,
+	}, {
+		Name: "graphql",
+		Data: `{"data": {"site": {"usageStatistics": {"daus": [{"userCount": 2}], "maus": [{"userCount": 300}]}}}}`,
+	}}
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			input, err := inventory.ImportUsage([]byte(tc.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			autogold.Equal(t, input)
+		})
+	}
+}
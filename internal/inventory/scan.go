@@ -0,0 +1,82 @@
+package inventory
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// Scan walks dir and returns the git repositories in it, which can be bare repositories (like
+// the ones gitserver stores) or working clones. Only the git directory of a working clone is
+// measured, as that is what gitserver stores. Directories which cannot be read are skipped, and
+// returned separately: they may hold any number of repositories, or none.
+func Scan(dir string) (repos []Repository, unreadable []string, err error) {
+	repos, unreadable, err = ScanFS(os.DirFS(dir))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %w", dir, err)
+	}
+	return repos, unreadable, nil
+}
+
+// ScanFS is like Scan, for the repositories in fsys. Names are relative to the root of fsys.
+func ScanFS(fsys fs.FS) (repos []Repository, unreadable []string, err error) {
+	err = fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == "." {
+				return err
+			}
+			unreadable = append(unreadable, name)
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		gitDir := ""
+		if isBare(fsys, name) {
+			gitDir = name
+		} else if dotGit := path.Join(name, ".git"); isBare(fsys, dotGit) {
+			gitDir = dotGit
+		}
+		if gitDir == "" {
+			return nil
+		}
+		repo := Repository{Name: name}
+		if size, err := dirSize(fsys, gitDir); err != nil {
+			repo.Missing = true
+		} else {
+			repo.Size = size
+		}
+		repos = append(repos, repo)
+		// Nested repositories, like submodules, are part of the repository.
+		return fs.SkipDir
+	})
+	return repos, unreadable, err
+}
+
+// isBare reports whether dir looks like a git directory.
+func isBare(fsys fs.FS, dir string) bool {
+	for _, name := range []string{"HEAD", "objects", "refs"} {
+		if _, err := fs.Stat(fsys, path.Join(dir, name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// dirSize returns the total size of the regular files in dir.
+func dirSize(fsys fs.FS, dir string) (int64, error) {
+	var size int64
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/sourcegraph/resource-estimator/internal/scaling"
+)
+
+// siteActivity is the number of active users per period, most recent first.
+type siteActivity struct {
+	DAUs []struct{ UserCount int }
+	MAUs []struct{ UserCount int }
+}
+
+// usageStatistics is the subset of a Sourcegraph pings payload used to derive estimator inputs.
+type usageStatistics struct {
+	TotalUsers      int           `json:"totalUsers"`
+	UsageStatistics *siteActivity `json:"usageStatistics"`
+	Repositories    *struct {
+		Count          int   `json:"count"`
+		TotalSizeBytes int64 `json:"totalSizeBytes"`
+	} `json:"repositories"`
+	RepositorySizeHistogram []struct {
+		GTE   int64  `json:"gte"`
+		LT    *int64 `json:"lt"`
+		Count int    `json:"count"`
+	} `json:"repositorySizeHistogram"`
+	CodeInsightsCriticalTelemetry *struct {
+		TotalInsights int
+	} `json:"codeInsightsCriticalTelemetry"`
+	NewCodeIntelUsage *struct {
+		NumRepositoriesWithUploadRecords int `json:"numRepositoriesWithUploadRecords"`
+	} `json:"newCodeIntelUsage"`
+
+	// Data is set when the file is the response of the site.usageStatistics GraphQL query.
+	Data *struct {
+		Site struct {
+			UsageStatistics *siteActivity `json:"usageStatistics"`
+		} `json:"site"`
+	} `json:"data"`
+}
+
+// ImportUsage derives estimator inputs from the usage statistics of an existing instance. It reads
+// the pings payload shown on the site admin Pings page, or the response of the
+// site.usageStatistics GraphQL query, which only contains active users.
+//
+// Users is the number of monthly active users (or of user accounts when there were none), and
+// EngagementRate is the ratio of daily to monthly active users. Code insights and precise code
+// intel are enabled when the instance uses them.
+func ImportUsage(data []byte) (Input, error) {
+	var stats usageStatistics
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return Input{}, err
+	}
+	activity := stats.UsageStatistics
+	if stats.Data != nil {
+		activity = stats.Data.Site.UsageStatistics
+	}
+	if activity == nil && stats.TotalUsers == 0 {
+		return Input{}, errors.New("no usage statistics found")
+	}
+
+	var input Input
+	var daily, monthly int
+	if activity != nil && len(activity.DAUs) > 0 {
+		daily = activity.DAUs[0].UserCount
+	}
+	if activity != nil && len(activity.MAUs) > 0 {
+		monthly = activity.MAUs[0].UserCount
+	}
+	input.Users = monthly
+	if input.Users == 0 {
+		input.Users = stats.TotalUsers
+	}
+	if input.Users > 0 && daily > 0 {
+		rate := math.Round(float64(daily) / float64(input.Users) * 100)
+		input.EngagementRate = int(math.Min(math.Max(rate, scaling.EngagementRateRange.Min), scaling.EngagementRateRange.Max))
+	}
+
+	if stats.Repositories != nil {
+		input.Repositories = stats.Repositories.Count
+		input.TotalRepoSize = int(math.Ceil(float64(stats.Repositories.TotalSizeBytes) / bytesPerGB))
+	}
+	if len(stats.RepositorySizeHistogram) > 0 {
+		d := &scaling.RepoSizeDistribution{}
+		for _, b := range stats.RepositorySizeHistogram {
+			// The last bucket has no upper bound, assume its repositories are up to twice as
+			// large as its lower bound.
+			max := float64(b.GTE) * 2
+			if b.LT != nil {
+				max = float64(*b.LT)
+			}
+			d.Buckets = append(d.Buckets, scaling.RepoSizeBucket{MaxSizeGB: max / bytesPerGB, Count: b.Count})
+		}
+		input.RepoSizeDistribution = d
+	}
+
+	if stats.CodeInsightsCriticalTelemetry != nil {
+		input.CodeInsight = "Disable"
+		if stats.CodeInsightsCriticalTelemetry.TotalInsights > 0 {
+			input.CodeInsight = "Enable"
+		}
+	}
+	if stats.NewCodeIntelUsage != nil && stats.NewCodeIntelUsage.NumRepositoriesWithUploadRecords > 0 {
+		input.LargestIndexSize = int(scaling.LargestIndexSizeRange.Min)
+	}
+	return input, nil
+}
@@ -0,0 +1,172 @@
+package scaling
+
+import "strings"
+
+// HorizontalPodAutoscaler is an autoscaling recommendation for a stateless service.
+type HorizontalPodAutoscaler struct {
+	// Service is the name of the service in Estimate.Services.
+	Service string
+	// Kind and Name identify the workload to scale.
+	Kind, Name               string
+	MinReplicas, MaxReplicas int
+	TargetCPUUtilization     int
+}
+
+// Autoscaling is the autoscaling configuration of a service in the Helm chart.
+type Autoscaling struct {
+	Enabled                        bool `json:"enabled"`
+	MinReplicas                    int  `json:"minReplicas"`
+	MaxReplicas                    int  `json:"maxReplicas"`
+	TargetCPUUtilizationPercentage int  `json:"targetCPUUtilizationPercentage"`
+}
+
+// statelessServices lists the services which can be scaled horizontally, the kind of their
+// workload and the CPU utilization to target when autoscaling. searcher targets a lower
+// utilization as unindexed search latency suffers first under load.
+var statelessServices = []struct {
+	Service, Kind        string
+	TargetCPUUtilization int
+}{
+	{Service: "frontend", Kind: "Deployment", TargetCPUUtilization: 70},
+	{Service: "searcher", Kind: "StatefulSet", TargetCPUUtilization: 60},
+	{Service: "symbols", Kind: "StatefulSet", TargetCPUUtilization: 70},
+	{Service: "syntectServer", Kind: "Deployment", TargetCPUUtilization: 80},
+	{Service: "preciseCodeIntel", Kind: "Deployment", TargetCPUUtilization: 80},
+}
+
+// nextReferenceReplicas returns the replicas of the reference point above the estimate for the
+// first reference of the service that specifies replicas, or of the load reference above the
+// search load when it is higher, or 0 if there is none.
+func (e *Estimate) nextReferenceReplicas(service string) int {
+	next := func(refs []ServiceScale, skipZero bool) int {
+		for _, ref := range refs {
+			if ref.ServiceName != service || !specifiesReplicas(ref.ReferencePoints) {
+				continue
+			}
+			value := e.factorValue(ref.ScalingFactor)
+			if skipZero && value == 0 {
+				continue
+			}
+			for _, point := range ref.ReferencePoints {
+				if point.Value > value {
+					return point.Replicas
+				}
+			}
+			return 0
+		}
+		return 0
+	}
+	replicas := next(References, false)
+	// Load references only apply under load, like in Calculate.
+	if load := next(LoadReferences, true); load > replicas {
+		return load
+	}
+	return replicas
+}
+
+func specifiesReplicas(refs []Service) bool {
+	for _, ref := range refs {
+		if ref.Replicas > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HorizontalPodAutoscalers recommends autoscaling for the stateless services. The minimum is the
+// estimated replica count, and the maximum is the replica count of the next reference or load
+// reference point up.
+// Services which would not scale beyond their estimate are omitted.
+func (e *Estimate) HorizontalPodAutoscalers() []HorizontalPodAutoscaler {
+	var hpas []HorizontalPodAutoscaler
+	for _, s := range statelessServices {
+		service, ok := e.Services[s.Service]
+		if !ok {
+			continue
+		}
+		hpa := HorizontalPodAutoscaler{
+			Service:              s.Service,
+			Kind:                 s.Kind,
+			Name:                 service.Label,
+			MinReplicas:          service.Replicas,
+			MaxReplicas:          e.nextReferenceReplicas(s.Service),
+			TargetCPUUtilization: s.TargetCPUUtilization,
+		}
+		if hpa.MaxReplicas <= hpa.MinReplicas {
+			continue
+		}
+		hpas = append(hpas, hpa)
+	}
+	return hpas
+}
+
+type hpaManifest struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   objectMetadata `json:"metadata"`
+	Spec       hpaSpec        `json:"spec"`
+}
+
+type objectMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// metadata returns the metadata of a manifest of the estimate, in the namespace of the instance
+// when it is part of a cluster.
+func (e *Estimate) metadata(name string) objectMetadata {
+	return objectMetadata{Name: name, Namespace: e.namespace}
+}
+
+type hpaSpec struct {
+	ScaleTargetRef hpaScaleTargetRef `json:"scaleTargetRef"`
+	MinReplicas    int               `json:"minReplicas"`
+	MaxReplicas    int               `json:"maxReplicas"`
+	Metrics        []hpaMetric       `json:"metrics"`
+}
+
+type hpaScaleTargetRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+type hpaMetric struct {
+	Type     string            `json:"type"`
+	Resource hpaResourceMetric `json:"resource"`
+}
+
+type hpaResourceMetric struct {
+	Name   string    `json:"name"`
+	Target hpaTarget `json:"target"`
+}
+
+type hpaTarget struct {
+	Type               string `json:"type"`
+	AverageUtilization int    `json:"averageUtilization"`
+}
+
+// HPAExport returns the recommended HorizontalPodAutoscaler manifests.
+func (e *Estimate) HPAExport() string {
+	var docs []string
+	for _, hpa := range e.HorizontalPodAutoscalers() {
+		docs = append(docs, toYAML(hpaManifest{
+			APIVersion: "autoscaling/v2",
+			Kind:       "HorizontalPodAutoscaler",
+			Metadata:   e.metadata(hpa.Name),
+			Spec: hpaSpec{
+				ScaleTargetRef: hpaScaleTargetRef{APIVersion: "apps/v1", Kind: hpa.Kind, Name: hpa.Name},
+				MinReplicas:    hpa.MinReplicas,
+				MaxReplicas:    hpa.MaxReplicas,
+				Metrics: []hpaMetric{{
+					Type: "Resource",
+					Resource: hpaResourceMetric{
+						Name:   "cpu",
+						Target: hpaTarget{Type: "Utilization", AverageUtilization: hpa.TargetCPUUtilization},
+					},
+				}},
+			},
+		}))
+	}
+	return strings.Join(docs, "---\n")
+}
@@ -0,0 +1,138 @@
+package scaling
+
+import (
+	"sort"
+	"strings"
+)
+
+// highAvailabilityReplicas is the minimum number of replicas of a stateless service in high
+// availability mode, so that one replica can be disrupted at a time.
+const highAvailabilityReplicas = 2
+
+// applyHighAvailability raises stateless services to the minimum high availability replica count,
+// and returns the vCPUs and memory required by the additional replicas.
+func (e *Estimate) applyHighAvailability() (cpu, memoryGB float64) {
+	for _, s := range statelessServices {
+		v, ok := e.Services[s.Service]
+		if !ok || v.Replicas >= highAvailabilityReplicas {
+			continue
+		}
+		added := float64(highAvailabilityReplicas - v.Replicas)
+		// Like the totals, count the requests plus 50% of the difference in limits.
+		cpu += added * (v.Resources.Requests.CPU + (v.Resources.Limits.CPU-v.Resources.Requests.CPU)/2)
+		memoryGB += added * (v.Resources.Requests.MEM + (v.Resources.Limits.MEM-v.Resources.Requests.MEM)/2)
+		v.Replicas = highAvailabilityReplicas
+		e.Services[s.Service] = v
+	}
+	return cpu, memoryGB
+}
+
+// PodDisruptionBudget is a disruption budget recommendation for a replicated stateless service.
+type PodDisruptionBudget struct {
+	// Service is the name of the service in Estimate.Services.
+	Service, Name  string
+	Replicas       int
+	MaxUnavailable int
+}
+
+// PodDisruptionBudgetValues is the disruption budget of a service in the Helm chart.
+type PodDisruptionBudgetValues struct {
+	Enabled        bool `json:"enabled"`
+	MaxUnavailable int  `json:"maxUnavailable"`
+}
+
+// TopologySpreadConstraint spreads the replicas of a service across nodes or zones.
+type TopologySpreadConstraint struct {
+	MaxSkew           int           `json:"maxSkew"`
+	TopologyKey       string        `json:"topologyKey"`
+	WhenUnsatisfiable string        `json:"whenUnsatisfiable"`
+	LabelSelector     labelSelector `json:"labelSelector"`
+}
+
+type labelSelector struct {
+	MatchLabels map[string]string `json:"matchLabels"`
+}
+
+// topologySpreadConstraints spreads replicas across zones where possible, and across nodes.
+func topologySpreadConstraints(name string) []TopologySpreadConstraint {
+	var constraints []TopologySpreadConstraint
+	for _, key := range []string{"topology.kubernetes.io/zone", "kubernetes.io/hostname"} {
+		constraints = append(constraints, TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       key,
+			WhenUnsatisfiable: "ScheduleAnyway",
+			LabelSelector:     labelSelector{MatchLabels: map[string]string{"app": name}},
+		})
+	}
+	return constraints
+}
+
+// PodDisruptionBudgets recommends disruption budgets for the replicated stateless services, so
+// that node drains evict at most one replica at a time. There are none unless high availability is
+// on for a Kubernetes deployment.
+func (e *Estimate) PodDisruptionBudgets() []PodDisruptionBudget {
+	if !e.HighAvailability || e.DeploymentType != "kubernetes" {
+		return nil
+	}
+	var pdbs []PodDisruptionBudget
+	for _, s := range statelessServices {
+		service, ok := e.Services[s.Service]
+		if !ok || service.Replicas < highAvailabilityReplicas {
+			continue
+		}
+		pdbs = append(pdbs, PodDisruptionBudget{
+			Service:        s.Service,
+			Name:           service.Label,
+			Replicas:       service.Replicas,
+			MaxUnavailable: 1,
+		})
+	}
+	return pdbs
+}
+
+// singleReplicaServices returns the labels of the stateful (or singleton) services which run a
+// single replica, and are therefore unavailable while their pod is rescheduled.
+func (e *Estimate) singleReplicaServices() []string {
+	stateless := map[string]struct{}{}
+	for _, s := range statelessServices {
+		stateless[s.Service] = struct{}{}
+	}
+	var labels []string
+	for name, service := range e.Services {
+		if _, ok := stateless[name]; ok || service.Replicas > 1 {
+			continue
+		}
+		labels = append(labels, service.Label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+type pdbManifest struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   objectMetadata `json:"metadata"`
+	Spec       pdbSpec        `json:"spec"`
+}
+
+type pdbSpec struct {
+	MaxUnavailable int           `json:"maxUnavailable"`
+	Selector       labelSelector `json:"selector"`
+}
+
+// PDBExport returns the recommended PodDisruptionBudget manifests.
+func (e *Estimate) PDBExport() string {
+	var docs []string
+	for _, pdb := range e.PodDisruptionBudgets() {
+		docs = append(docs, toYAML(pdbManifest{
+			APIVersion: "policy/v1",
+			Kind:       "PodDisruptionBudget",
+			Metadata:   e.metadata(pdb.Name),
+			Spec: pdbSpec{
+				MaxUnavailable: pdb.MaxUnavailable,
+				Selector:       labelSelector{MatchLabels: map[string]string{"app": pdb.Name}},
+			},
+		}))
+	}
+	return strings.Join(docs, "---\n")
+}
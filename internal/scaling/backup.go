@@ -0,0 +1,126 @@
+package scaling
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	DefaultBackupFrequencyHours = 24
+	DefaultBackupRetentionCount = 7
+
+	// A reduced standby site serves about 25% of the engaged users until it is scaled up after a
+	// failover.
+	standbyUserRatio = 0.25
+)
+
+// The warm-standby modes: no standby region, a standby site of the same size as the primary one,
+// or a reduced standby site.
+const (
+	StandbyNone    = "none"
+	StandbyFull    = "full"
+	StandbyReduced = "reduced"
+)
+
+var (
+	BackupFrequencyHoursRange = Range{0, 168}
+	BackupRetentionCountRange = Range{0, 365}
+)
+
+// backupServices are the services whose volumes are backed up, and the share of their data that
+// changes every day. The first snapshot is full, and the others only keep the changes since the
+// previous one.
+var backupServices = []struct {
+	Service     string
+	DailyChange float64
+}{
+	{"pgsql", 0.05},
+	{"codeintel-db", 0.1},
+	{"codeinsights-db", 0.05},
+	{"gitserver", 0.02},
+	// Uploads are replaced as new commits are indexed.
+	{"blobstore", 0.2},
+}
+
+// backupChanges describes the daily change of the data of the services backed up.
+func backupChanges() string {
+	var changes []string
+	for _, s := range backupServices {
+		changes = append(changes, fmt.Sprintf("%v%% a day for %v", s.DailyChange*100, s.Service))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// BackupPlan is the storage required to keep the snapshots of the stateful services.
+type BackupPlan struct {
+	FrequencyHours, RetentionCount int
+	Volumes                        []VolumeBackup
+	TotalGB                        float64
+}
+
+// VolumeBackup is the storage required by the snapshots of the volumes of a service, across all
+// its replicas.
+type VolumeBackup struct {
+	Service            string
+	VolumeGB, BackupGB float64
+}
+
+func (e *Estimate) backupFrequencyHours() int {
+	if e.BackupFrequencyHours <= 0 {
+		return DefaultBackupFrequencyHours
+	}
+	return e.BackupFrequencyHours
+}
+
+func (e *Estimate) backupRetentionCount() int {
+	if e.BackupRetentionCount <= 0 {
+		return DefaultBackupRetentionCount
+	}
+	return e.BackupRetentionCount
+}
+
+func (e *Estimate) warmStandby() string {
+	switch e.WarmStandby {
+	case StandbyFull, StandbyReduced:
+		return e.WarmStandby
+	default:
+		return StandbyNone
+	}
+}
+
+// Backups returns the storage required to keep the snapshots of the stateful services, from the
+// size of their volumes.
+func (e *Estimate) Backups() BackupPlan {
+	p := BackupPlan{FrequencyHours: e.backupFrequencyHours(), RetentionCount: e.backupRetentionCount()}
+	for _, s := range backupServices {
+		v, ok := e.Services[s.Service]
+		if !ok || v.Storage == 0 {
+			continue
+		}
+		volumeGB := v.Storage * math.Max(float64(v.Replicas), 1)
+		change := math.Min(s.DailyChange*float64(p.FrequencyHours)/24, 1)
+		backupGB := math.Ceil(volumeGB * (1 + float64(p.RetentionCount-1)*change))
+		p.Volumes = append(p.Volumes, VolumeBackup{Service: v.Label, VolumeGB: volumeGB, BackupGB: backupGB})
+		p.TotalGB += backupGB
+	}
+	return p
+}
+
+// StandbySite returns the estimate of the warm-standby site, or nil without one. It holds all the
+// data of the primary site; a reduced site only serves part of the engaged users, without high
+// availability, until it is scaled up after a failover.
+func (e *Estimate) StandbySite() *Estimate {
+	mode := e.warmStandby()
+	if mode == StandbyNone {
+		return nil
+	}
+	site := *e
+	site.WarmStandby = ""
+	site.Growth = nil
+	if mode == StandbyReduced {
+		site.EngagementRate = int(math.Ceil(float64(e.EngagedUsers) * standbyUserRatio * 100 / math.Max(float64(e.Users), 1)))
+		site.HighAvailability = false
+	}
+	return site.Calculate()
+}
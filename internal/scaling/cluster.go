@@ -0,0 +1,187 @@
+package scaling
+
+import (
+	"math"
+	"sort"
+)
+
+// Kubernetes reserves about 10% of a node for the kubelet and system daemons.
+const nodeReservedRatio = 0.1
+
+// Cluster is several Sourcegraph instances sharing one Kubernetes cluster, each in its own
+// namespace.
+type Cluster struct {
+	Instances []ClusterInstance
+}
+
+// ClusterInstance is the estimate of one of the instances of a cluster.
+type ClusterInstance struct {
+	Namespace string
+	Estimate  *Estimate
+}
+
+// ClusterReport is the combined resources of the instances of a cluster, and the node pool they
+// are packed onto.
+type ClusterReport struct {
+	Instances []InstanceUsage
+	// CPU and MemoryGB are the requests and limits of every replica of every instance.
+	CPU, MemoryGB ResourceRange
+	StorageGB     float64
+	Pods          int
+	NodePool      NodePool
+	Nodes         []ClusterNode
+	// Unschedulable are the pods whose requests do not fit on the largest node shape.
+	Unschedulable []string
+}
+
+// InstanceUsage is the resources of every replica of an instance, and the quota of its namespace.
+type InstanceUsage struct {
+	Namespace     string
+	Estimate      *Estimate
+	Pods          int
+	CPU, MemoryGB ResourceRange
+	StorageGB     float64
+	Quota         ResourceQuota
+}
+
+// ClusterNode is a node of the pool, and the requests of the pods packed onto it.
+type ClusterNode struct {
+	CPU, MemoryGB float64
+	Pods          []string
+}
+
+// clusterPod is a replica of a pod of an instance. Storage is the size of its volumes.
+type clusterPod struct {
+	Name             string
+	Requests, Limits Resource
+	Storage          float64
+}
+
+// clusterPods returns a pod per replica of the services of the estimate, and of the deployed
+// services without an estimate, at their defaults. Services sharing a pod are combined.
+func (e *Estimate) clusterPods(namespace string) []clusterPod {
+	podOf := map[string]string{}
+	for pod, services := range pods {
+		for _, name := range services {
+			podOf[name] = pod
+		}
+	}
+	type group struct {
+		pod      clusterPod
+		replicas int
+	}
+	groups := map[string]*group{}
+	add := func(name string, s Service) {
+		pod := name
+		if p, ok := podOf[name]; ok {
+			pod = p
+		}
+		g, ok := groups[pod]
+		if !ok {
+			g = &group{pod: clusterPod{Name: namespace + "/" + pod}}
+			groups[pod] = g
+		}
+		g.pod.Requests.CPU += s.Resources.Requests.CPU
+		g.pod.Requests.MEM += s.Resources.Requests.MEM
+		g.pod.Limits.CPU += s.Resources.Limits.CPU
+		g.pod.Limits.MEM += s.Resources.Limits.MEM
+		// Ephemeral storage is the total of all the replicas.
+		replicas := math.Max(float64(s.Replicas), 1)
+		g.pod.Requests.EPH += s.Resources.Requests.EPH / replicas
+		g.pod.Limits.EPH += s.Resources.Limits.EPH / replicas
+		g.pod.Storage += s.Storage
+		g.replicas = int(math.Max(float64(g.replicas), replicas))
+	}
+	for name, s := range e.Services {
+		add(name, s)
+	}
+	for name, d := range defaults {
+		if _, ok := e.Services[name]; ok || !e.deployed(name) {
+			continue
+		}
+		if s, ok := d[e.DeploymentType]; ok {
+			add(name, s)
+		}
+	}
+	var all []clusterPod
+	for _, g := range groups {
+		for i := 0; i < g.replicas; i++ {
+			all = append(all, g.pod)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Calculate calculates the estimate of every instance as a Kubernetes deployment in its namespace,
+// and packs their pods onto the node pool with the fewest vCPUs.
+func (c *Cluster) Calculate() *ClusterReport {
+	r := &ClusterReport{}
+	var all []clusterPod
+	for _, instance := range c.Instances {
+		e := instance.Estimate
+		e.DeploymentType = "kubernetes"
+		e.namespace = instance.Namespace
+		e.Calculate()
+		u := InstanceUsage{Namespace: instance.Namespace, Estimate: e}
+		for _, pod := range e.clusterPods(instance.Namespace) {
+			u.Pods++
+			u.CPU = u.CPU.Add(ResourceRange{pod.Requests.CPU, pod.Limits.CPU})
+			u.MemoryGB = u.MemoryGB.Add(ResourceRange{pod.Requests.MEM, pod.Limits.MEM})
+			u.StorageGB += pod.Storage
+			all = append(all, pod)
+		}
+		u.Quota = e.ResourceQuota()
+		r.Instances = append(r.Instances, u)
+		r.Pods += u.Pods
+		r.CPU = r.CPU.Add(u.CPU)
+		r.MemoryGB = r.MemoryGB.Add(u.MemoryGB)
+		r.StorageGB += u.StorageGB
+	}
+	if len(all) == 0 {
+		return r
+	}
+
+	// The scheduler places pods by their requests. Pack the largest ones first.
+	sort.SliceStable(all, func(i, j int) bool {
+		return math.Max(all[i].Requests.CPU, all[i].Requests.MEM/4) > math.Max(all[j].Requests.CPU, all[j].Requests.MEM/4)
+	})
+	for _, shape := range nodeShapes {
+		nodes, unschedulable := packPods(all, shape)
+		if len(unschedulable) > 0 && shape != nodeShapes[len(nodeShapes)-1] {
+			continue
+		}
+		if r.Nodes == nil || float64(len(nodes))*shape.CPU < float64(r.NodePool.Nodes)*r.NodePool.Shape.CPU {
+			r.NodePool = NodePool{Nodes: len(nodes), Shape: shape}
+			r.Nodes, r.Unschedulable = nodes, unschedulable
+		}
+	}
+	return r
+}
+
+// packPods packs the pods onto nodes of the given shape, first fit, and returns the pods whose
+// requests do not fit on an empty node.
+func packPods(all []clusterPod, shape NodeShape) (nodes []ClusterNode, unschedulable []string) {
+	cpu, mem := shape.CPU*(1-nodeReservedRatio), shape.MEM*(1-nodeReservedRatio)
+	for _, pod := range all {
+		if pod.Requests.CPU > cpu || pod.Requests.MEM > mem {
+			unschedulable = append(unschedulable, pod.Name)
+			continue
+		}
+		placed := false
+		for i := range nodes {
+			n := &nodes[i]
+			if n.CPU+pod.Requests.CPU <= cpu && n.MemoryGB+pod.Requests.MEM <= mem {
+				n.CPU += pod.Requests.CPU
+				n.MemoryGB += pod.Requests.MEM
+				n.Pods = append(n.Pods, pod.Name)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			nodes = append(nodes, ClusterNode{CPU: pod.Requests.CPU, MemoryGB: pod.Requests.MEM, Pods: []string{pod.Name}})
+		}
+	}
+	return nodes, unschedulable
+}
@@ -0,0 +1,99 @@
+package scaling
+
+import "math"
+
+const (
+	// A precise-code-intel-worker replica processes about 20GB of SCIP indexes an hour.
+	scipProcessGBPerWorkerHour = 20
+
+	// Uploads are not spread over the day: most are made by CI during working hours, so the peak
+	// rate is about 3 times the average.
+	scipUploadPeakFactor = 3
+
+	// maxSCIPWorkers is the most precise-code-intel-worker replicas recommended without contacting
+	// support.
+	maxSCIPWorkers = 50
+)
+
+var (
+	SCIPUploadsPerDayRange = Range{0, 100000}
+	AverageIndexSizeRange  = Range{0, 1000000}
+	SCIPLanguagesRange     = Range{0, 50}
+)
+
+// CodeIntelPlan is the volume of SCIP indexes uploaded for precise code intel, and the capacity
+// required to process and keep them.
+type CodeIntelPlan struct {
+	UploadsPerDay  float64 // across all languages
+	AverageIndexGB float64
+	// RetainedGB is the size of the uploads kept: the tip of the default branch for every
+	// language, and every upload made during the retention period.
+	RetainedGB  float64
+	BlobstoreGB float64
+	// Workers is the number of precise-code-intel-worker replicas required to process the uploads
+	// at peak, or 0 when the upload volume is not given.
+	Workers int
+}
+
+func (e *Estimate) scipLanguages() int {
+	if e.SCIPLanguages <= 0 {
+		return 1
+	}
+	return e.SCIPLanguages
+}
+
+// planCodeIntel calculates the upload volume of precise code intel. Without an upload volume, a
+// single upload of the largest index is assumed every day.
+func (e *Estimate) planCodeIntel() {
+	p := CodeIntelPlan{}
+	if e.LargestIndexSize == 0 {
+		e.CodeIntel = p
+		return
+	}
+	languages := float64(e.scipLanguages())
+	p.UploadsPerDay = scipUploadsPerDay * languages
+	if e.SCIPUploadsPerDay > 0 {
+		p.UploadsPerDay = float64(e.SCIPUploadsPerDay) * languages
+	}
+	p.AverageIndexGB = float64(e.LargestIndexSize)
+	if e.AverageIndexSizeMB > 0 {
+		p.AverageIndexGB = math.Min(float64(e.AverageIndexSizeMB)/1000, float64(e.LargestIndexSize))
+	}
+	retentionDays := float64(e.scipRetentionDays())
+	p.RetainedGB = p.AverageIndexGB * (languages + p.UploadsPerDay*retentionDays)
+
+	// Blobstore keeps the upload files, which must hold at least the largest index.
+	p.BlobstoreGB = float64(e.LargestIndexSize)
+	if e.SCIPUploadsPerDay > 0 {
+		p.BlobstoreGB = math.Ceil(math.Max(p.BlobstoreGB, p.UploadsPerDay*p.AverageIndexGB*retentionDays))
+		peakGBPerHour := p.UploadsPerDay * p.AverageIndexGB * scipUploadPeakFactor / 24
+		p.Workers = int(math.Ceil(peakGBPerHour / scipProcessGBPerWorkerHour))
+		if p.Workers > maxSCIPWorkers {
+			p.Workers = maxSCIPWorkers
+			e.ContactSupport = true
+		}
+	}
+	e.CodeIntel = p
+}
+
+// applyCodeIntelPlan raises the precise-code-intel-worker replicas to process the uploads, and
+// records which services were sized by the upload volume.
+func (e *Estimate) applyCodeIntelPlan() {
+	if e.CodeIntel.Workers == 0 {
+		return
+	}
+	for _, name := range []string{"blobstore", "codeintel-db", "preciseCodeIntel"} {
+		r, ok := e.Services[name]
+		if !ok {
+			continue
+		}
+		if name == "preciseCodeIntel" {
+			if e.CodeIntel.Workers <= r.Replicas {
+				continue
+			}
+			r.Replicas = e.CodeIntel.Workers
+		}
+		r.sizedBy(BySCIPUploads)
+		e.Services[name] = r
+	}
+}
@@ -0,0 +1,98 @@
+package scaling
+
+import "math"
+
+const (
+	// minDatabaseStorageGB is the smallest volume recommended for a database.
+	minDatabaseStorageGB = 200
+
+	// databaseHeadroom leaves room on database volumes for indexes, WAL, vacuuming and the copy
+	// of a table rewritten during migrations.
+	databaseHeadroom = 2
+
+	// pgsql stores the metadata of every repository (repo, gitserver_repos, permissions and
+	// search indexing state) and the activity of every user (event logs and settings).
+	pgsqlMBPerRepository = 0.2
+	pgsqlMBPerUser       = 5
+
+	// The SCIP data stored in codeintel-db is about the size of the uploaded indexes kept, see
+	// CodeIntelPlan. Without an upload volume, one upload a day is assumed.
+	scipStoredRatio          = 1
+	scipUploadsPerDay        = 1
+	DefaultSCIPRetentionDays = 7
+
+	// Every code insights series records a data point per repository and month, and keeps them
+	// for the retention period.
+	insightsBytesPerDataPoint      = 200
+	defaultInsightSeries           = 50
+	DefaultInsightsRetentionMonths = 12
+)
+
+var (
+	SCIPRetentionDaysRange       = Range{0, 365}
+	InsightsRetentionMonthsRange = Range{0, 120}
+)
+
+// DatabasePlan is the storage each database needs for the workload, before the minimum volume
+// size is applied. A database whose feature is disabled needs none.
+type DatabasePlan struct {
+	PgsqlGB, CodeIntelGB, CodeInsightsGB float64
+}
+
+func (e *Estimate) scipRetentionDays() int {
+	if e.SCIPRetentionDays <= 0 {
+		return DefaultSCIPRetentionDays
+	}
+	return e.SCIPRetentionDays
+}
+
+func (e *Estimate) insightSeries() int {
+	if e.InsightSeries <= 0 {
+		return defaultInsightSeries
+	}
+	return e.InsightSeries
+}
+
+func (e *Estimate) reposPerSeries() int {
+	if e.ReposPerSeries <= 0 || e.ReposPerSeries > e.Repositories {
+		return e.Repositories
+	}
+	return e.ReposPerSeries
+}
+
+// insightSeriesRepos is the value of the ByInsightSeriesRepos scaling factor: the number of
+// repositories code insights record data points for, counted once per series. It is 0 when code
+// insights are disabled.
+func (e *Estimate) insightSeriesRepos() float64 {
+	if e.CodeInsight != "Enable" {
+		return 0
+	}
+	return float64(e.insightSeries()) * float64(e.reposPerSeries())
+}
+
+func (e *Estimate) insightsRetentionMonths() int {
+	if e.InsightsRetentionMonths <= 0 {
+		return DefaultInsightsRetentionMonths
+	}
+	return e.InsightsRetentionMonths
+}
+
+// planDatabases calculates the storage of each database from the workload.
+func (e *Estimate) planDatabases() {
+	p := DatabasePlan{}
+	p.PgsqlGB = databaseHeadroom * (float64(e.AverageRepositories)*pgsqlMBPerRepository + float64(e.Users)*pgsqlMBPerUser) / 1000
+	if e.LargestIndexSize > 0 {
+		p.CodeIntelGB = databaseHeadroom * e.CodeIntel.RetainedGB * scipStoredRatio
+	}
+	if series := e.insightSeriesRepos(); series > 0 {
+		points := series * float64(e.insightsRetentionMonths())
+		p.CodeInsightsGB = databaseHeadroom * points * insightsBytesPerDataPoint / 1e9
+	}
+	e.Databases = p
+}
+
+// databaseStorage returns the storage of the database for the workload, and at least the minimum
+// volume size.
+func databaseStorage(model float64) float64 {
+	return math.Max(math.Ceil(model), minDatabaseStorageGB)
+}
@@ -0,0 +1,147 @@
+package scaling
+
+import (
+	"math"
+	"sort"
+)
+
+// monorepoSizeGB is the size above which a repository is considered a monorepo.
+const monorepoSizeGB = 2
+
+// hotRepositories is the number of the largest repositories searcher and symbols are expected to
+// have in their caches at the same time.
+const hotRepositories = 3
+
+// RepoSizeBucket is a bucket of a repository size histogram.
+type RepoSizeBucket struct {
+	// MaxSizeGB is the upper bound of the bucket. The lower bound is the upper bound of the
+	// previous bucket, or 0 for the first one.
+	MaxSizeGB float64 `json:"maxSizeGB"`
+	Count     int     `json:"count"`
+}
+
+// RepoSizeDistribution describes the shape of the corpus, which can be given as a histogram of
+// repository sizes, as the sizes of the largest repositories, or both.
+type RepoSizeDistribution struct {
+	Buckets []RepoSizeBucket `json:"buckets,omitempty"`
+	// TopRepositories are the sizes in GB of the largest repositories. They are not counted in
+	// Buckets.
+	TopRepositories []float64 `json:"topRepositories,omitempty"`
+}
+
+// repoSize is a number of repositories of the same size.
+type repoSize struct {
+	SizeGB float64
+	Count  int
+}
+
+// sizes returns the repositories of the distribution, using the middle of each bucket as the
+// size of its repositories. When there are no buckets, the repositories not listed in
+// TopRepositories share the rest of the total repository size.
+func (d *RepoSizeDistribution) sizes(repositories, totalRepoSize int) []repoSize {
+	var sizes []repoSize
+	for _, size := range d.TopRepositories {
+		sizes = append(sizes, repoSize{SizeGB: size, Count: 1})
+	}
+	buckets := append([]RepoSizeBucket(nil), d.Buckets...)
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].MaxSizeGB < buckets[j].MaxSizeGB })
+	var min float64
+	for _, b := range buckets {
+		if b.Count > 0 {
+			sizes = append(sizes, repoSize{SizeGB: (min + b.MaxSizeGB) / 2, Count: b.Count})
+		}
+		min = b.MaxSizeGB
+	}
+	if len(buckets) == 0 {
+		rest := repositories - len(d.TopRepositories)
+		restSize := float64(totalRepoSize) - d.sum(len(d.TopRepositories))
+		if rest > 0 && restSize > 0 {
+			sizes = append(sizes, repoSize{SizeGB: restSize / float64(rest), Count: rest})
+		}
+	}
+	return sizes
+}
+
+// monorepos returns the number of repositories of the distribution larger than a monorepo.
+func (d *RepoSizeDistribution) monorepos(repositories, totalRepoSize int) int {
+	var monorepos int
+	for _, s := range d.sizes(repositories, totalRepoSize) {
+		if s.SizeGB > monorepoSizeGB {
+			monorepos += s.Count
+		}
+	}
+	return monorepos
+}
+
+// largestBucket returns the upper bound of the largest non-empty bucket.
+func (d *RepoSizeDistribution) largestBucket() float64 {
+	var largest float64
+	for _, b := range d.Buckets {
+		if b.Count > 0 && b.MaxSizeGB > largest {
+			largest = b.MaxSizeGB
+		}
+	}
+	return largest
+}
+
+// sum returns the total size of the n largest repositories in TopRepositories.
+func (d *RepoSizeDistribution) sum(n int) float64 {
+	top := append([]float64(nil), d.TopRepositories...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(top)))
+	var sum float64
+	for i := 0; i < n && i < len(top); i++ {
+		sum += top[i]
+	}
+	return sum
+}
+
+// applyRepoSizeDistribution derives the repository count, total and largest repository size and
+// the number of monorepos from the size distribution, and counts every additional 2GB of a
+// monorepo as one more average repository. A repository count, total size or number of monorepos
+// given along with the distribution is kept, as it is more precise than the histogram. The
+// repositories missing from the histogram are counted like those in it.
+func (e *Estimate) applyRepoSizeDistribution() {
+	d := e.RepoSizeDistribution
+	if d == nil {
+		e.AverageRepositories = e.Repositories + e.LargeMonorepos*MonorepoFactor
+		return
+	}
+	sizes := d.sizes(e.Repositories, e.TotalRepoSize)
+	var (
+		repositories, monorepos, effective int
+		total                              float64
+	)
+	for _, s := range sizes {
+		repositories += s.Count
+		total += s.SizeGB * float64(s.Count)
+		effective += s.Count
+		if s.SizeGB > monorepoSizeGB {
+			monorepos += s.Count
+			effective += s.Count * (int(math.Ceil(s.SizeGB/monorepoSizeGB)) - 1) * MonorepoFactor
+		}
+	}
+	if e.Repositories == 0 {
+		e.Repositories = repositories
+	}
+	if e.Repositories > repositories && repositories > 0 {
+		effective = int(math.Ceil(float64(effective) * float64(e.Repositories) / float64(repositories)))
+	}
+	if e.TotalRepoSize == 0 {
+		e.TotalRepoSize = int(math.Ceil(total))
+	}
+	e.LargestRepoSize = int(math.Ceil(math.Max(float64(e.LargestRepoSize), math.Max(d.sum(1), d.largestBucket()))))
+	if e.LargeMonorepos == 0 {
+		e.LargeMonorepos = monorepos
+	}
+	e.AverageRepositories = effective
+}
+
+// hotRepoSize returns the size in GB of the repositories searcher and symbols must be able to
+// hold at the same time: the few largest repositories when they are known, and otherwise the
+// largest repository.
+func (e *Estimate) hotRepoSize() float64 {
+	if e.RepoSizeDistribution != nil && len(e.RepoSizeDistribution.TopRepositories) > 0 {
+		return math.Max(e.RepoSizeDistribution.sum(hotRepositories), float64(e.LargestRepoSize))
+	}
+	return float64(e.LargestRepoSize)
+}
@@ -0,0 +1,32 @@
+package scaling
+
+import "math"
+
+// DefaultPeakConcurrency is the percentage of engaged users active at the same time at peak which
+// the reference points of services scaling by engaged users were measured with.
+const DefaultPeakConcurrency = 20
+
+// applyEngagement computes the engaged users from the number of users and the engagement rate,
+// and the peak concurrent users from the engaged users and the peak concurrency.
+func (e *Estimate) applyEngagement() {
+	rate := float64(e.EngagementRate)
+	if rate == 0 {
+		rate = EngagementRateRange.Max
+	}
+	rate = math.Min(math.Max(rate, EngagementRateRange.Min), EngagementRateRange.Max)
+	e.EngagedUsers = int(math.Ceil(float64(e.Users) * rate / 100))
+	e.PeakUsers = int(math.Ceil(float64(e.EngagedUsers) * float64(e.peakConcurrency()) / 100))
+}
+
+func (e *Estimate) peakConcurrency() int {
+	if e.PeakConcurrency == 0 {
+		return DefaultPeakConcurrency
+	}
+	return int(math.Min(math.Max(float64(e.PeakConcurrency), PeakConcurrencyRange.Min), PeakConcurrencyRange.Max))
+}
+
+// engagedUsersLoad is the value of the ByEngagedUsers scaling factor: the number of engaged users
+// with the default peak concurrency that would put the same load on the instance.
+func (e *Estimate) engagedUsersLoad() float64 {
+	return float64(e.EngagedUsers) * float64(e.peakConcurrency()) / DefaultPeakConcurrency
+}
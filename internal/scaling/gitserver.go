@@ -0,0 +1,75 @@
+package scaling
+
+import "math"
+
+// GitserverShardPlan describes how repositories are spread across gitserver replicas (shards).
+type GitserverShardPlan struct {
+	Shards int
+	// RepositoryShards is the number of shards recommended for the number of repositories alone.
+	RepositoryShards int
+	// PVCSize is the size of each shard's volume in GB: its share of the repositories plus room
+	// for the largest repository, which lives on a single shard.
+	PVCSize float64
+	// Headroom is the room in GB kept on each shard for the largest repositories. When the sizes
+	// of the largest repositories are known, it covers the ones that may land on the same shard.
+	Headroom float64
+	// Fits is false when the largest repository does not fit in a volume of the maximum size.
+	Fits bool
+}
+
+// planGitserverShards picks the number of gitserver shards so that each shard's volume, holding
+// its share of the repositories plus the largest repository, stays within the maximum volume
+// size. The gitserver replica count and storage are updated to match the plan.
+func (e *Estimate) planGitserverShards() {
+	v, ok := e.Services["gitserver"]
+	if !ok {
+		return
+	}
+	// 30% more than the total repo size, for git housekeeping.
+	data := float64(e.TotalRepoSize) * 1.3
+	plan := GitserverShardPlan{
+		RepositoryShards: int(math.Max(float64(v.Replicas), 1)),
+		Fits:             true,
+	}
+	plan.Shards = plan.RepositoryShards
+	if max := float64(e.GitserverMaxVolumeSize); max > 0 {
+		// Adding shards spreads the largest repositories further, so the headroom can only
+		// shrink and this converges.
+		for {
+			room := max - e.gitserverHeadroom(plan.Shards)
+			if room <= 0 {
+				// The largest repository does not fit on a volume of the maximum size.
+				plan.Fits = false
+				v.ContactSupport = true
+				e.ContactSupport = true
+				break
+			}
+			shards := int(math.Ceil(data / room))
+			if shards <= plan.Shards {
+				break
+			}
+			plan.Shards = shards
+		}
+	}
+	plan.Headroom = e.gitserverHeadroom(plan.Shards)
+	plan.PVCSize = math.Ceil(data/float64(plan.Shards) + plan.Headroom)
+	v.Replicas = plan.Shards
+	v.setStorage(plan.PVCSize)
+	v.sizedBy(ByTotalRepoSize)
+	e.Services["gitserver"] = v
+	e.GitserverShards = plan
+}
+
+// gitserverHeadroom returns the room each of the given number of shards keeps for the largest
+// repositories. Repositories are assigned to shards by hashing their names, so when the sizes
+// of the largest repositories are known each shard keeps room for its even share of them,
+// starting with the largest.
+func (e *Estimate) gitserverHeadroom(shards int) float64 {
+	largest := float64(e.LargestRepoSize)
+	d := e.RepoSizeDistribution
+	if d == nil || len(d.TopRepositories) == 0 {
+		return largest
+	}
+	n := int(math.Ceil(float64(len(d.TopRepositories)) / float64(shards)))
+	return math.Max(d.sum(n), largest)
+}
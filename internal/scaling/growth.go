@@ -0,0 +1,183 @@
+package scaling
+
+import (
+	"math"
+	"sort"
+)
+
+// Growth describes how the instance is expected to grow over a planning horizon.
+type Growth struct {
+	RepositoriesPerMonth int
+	// RepoSizeGrowth is the percentage by which repositories grow every month. New repositories
+	// are of the current average size, or follow the buckets of the size distribution, and hold
+	// as many monorepos in proportion as the current ones.
+	RepoSizeGrowth float64
+	UsersPerMonth  int
+	// IndexGrowth is the percentage by which SCIP indexes grow every month.
+	IndexGrowth float64
+	// HorizonMonths is the planning horizon, and IntervalMonths how often the estimate is
+	// calculated within it.
+	HorizonMonths, IntervalMonths int
+}
+
+// DefaultHorizonMonths and DefaultIntervalMonths are used when the growth does not set them.
+const (
+	DefaultHorizonMonths  = 12
+	DefaultIntervalMonths = 3
+)
+
+// pvcServices are the services whose volumes are hard to resize, and are recommended an initial
+// size which covers the whole horizon.
+var pvcServices = []string{"gitserver", "pgsql", "indexedSearch", "blobstore"}
+
+// Projection is the estimate calculated at every interval of the planning horizon.
+type Projection struct {
+	Points    []ProjectionPoint
+	Crossings []ReferenceCrossing
+	PVCs      []PVCRecommendation
+}
+
+// ProjectionPoint is the estimate after a number of months.
+type ProjectionPoint struct {
+	Month    int
+	Estimate *Estimate
+}
+
+// ReferenceCrossing is a service crossing one of its reference points, after which it is sized
+// by the next reference point up.
+type ReferenceCrossing struct {
+	Month   int
+	Service string
+	Factor  Factor
+	Value   float64
+}
+
+// PVCRecommendation is the initial volume size, per replica, which covers the whole horizon
+// without adding replicas.
+type PVCRecommendation struct {
+	Service          string
+	Now, Recommended float64
+}
+
+func (g *Growth) horizon() (months, interval int) {
+	months, interval = g.HorizonMonths, g.IntervalMonths
+	if months <= 0 {
+		months = DefaultHorizonMonths
+	}
+	if interval <= 0 {
+		interval = DefaultIntervalMonths
+	}
+	return months, interval
+}
+
+// grow returns the inputs of the estimate after the given number of months.
+func (e *Estimate) grow(months int) *Estimate {
+	g := e.Growth
+	grown := *e
+	grown.Growth = nil
+	sizeFactor := math.Pow(1+g.RepoSizeGrowth/100, float64(months))
+	averageRepoSize := float64(e.TotalRepoSize) / math.Max(float64(e.Repositories), 1)
+	newRepositories := g.RepositoriesPerMonth * months
+
+	grown.Users = e.Users + g.UsersPerMonth*months
+	grown.Repositories = e.Repositories + newRepositories
+	grown.TotalRepoSize = int(math.Ceil((float64(e.TotalRepoSize) + averageRepoSize*float64(newRepositories)) * sizeFactor))
+	grown.LargestRepoSize = int(math.Ceil(float64(e.LargestRepoSize) * sizeFactor))
+	// Monorepos are as common among the new repositories as among the current ones.
+	if e.Repositories > 0 {
+		grown.LargeMonorepos = int(math.Floor(float64(e.LargeMonorepos) * float64(grown.Repositories) / float64(e.Repositories)))
+	}
+	if e.LargestIndexSize > 0 {
+		indexFactor := math.Pow(1+g.IndexGrowth/100, float64(months))
+		grown.LargestIndexSize = int(math.Ceil(float64(e.LargestIndexSize) * indexFactor))
+		grown.AverageIndexSizeMB = int(math.Ceil(float64(e.AverageIndexSizeMB) * indexFactor))
+		// The code of the repositories grows with their indexes.
+		grown.IndexSizeRepositories = nil
+		for _, repo := range e.IndexSizeRepositories {
+			repo.LinesOfCode = int(math.Ceil(float64(repo.LinesOfCode) * indexFactor))
+			grown.IndexSizeRepositories = append(grown.IndexSizeRepositories, repo)
+		}
+	}
+	if d := e.RepoSizeDistribution; d != nil {
+		// New repositories are spread across the buckets like the current ones.
+		var bucketed int
+		for _, b := range d.Buckets {
+			bucketed += b.Count
+		}
+		countFactor := 1.0
+		if bucketed > 0 {
+			countFactor = float64(bucketed+newRepositories) / float64(bucketed)
+		}
+		scaled := &RepoSizeDistribution{}
+		for _, b := range d.Buckets {
+			count := int(math.Ceil(float64(b.Count) * countFactor))
+			scaled.Buckets = append(scaled.Buckets, RepoSizeBucket{MaxSizeGB: b.MaxSizeGB * sizeFactor, Count: count})
+		}
+		for _, size := range d.TopRepositories {
+			scaled.TopRepositories = append(scaled.TopRepositories, size*sizeFactor)
+		}
+		grown.RepoSizeDistribution = scaled
+		// Repositories growing past the monorepo size become monorepos.
+		grown.LargeMonorepos = int(math.Max(float64(grown.LargeMonorepos), float64(scaled.monorepos(grown.Repositories, grown.TotalRepoSize))))
+	}
+	return &grown
+}
+
+// Projection calculates the estimate at every interval of the planning horizon, and reports when
+// services cross their reference points and which volume sizes cover the whole horizon. It
+// returns nil if the estimate has no growth.
+func (e *Estimate) Projection() *Projection {
+	if e.Growth == nil {
+		return nil
+	}
+	months, interval := e.Growth.horizon()
+	p := &Projection{}
+	for month := 0; month <= months; month += interval {
+		p.Points = append(p.Points, ProjectionPoint{Month: month, Estimate: e.grow(month).Calculate()})
+		if month < months && month+interval > months {
+			// Always end at the horizon.
+			month = months - interval
+		}
+	}
+
+	crossed := map[ReferenceCrossing]struct{}{}
+	for i := 1; i < len(p.Points); i++ {
+		before, after := p.Points[i-1].Estimate, p.Points[i].Estimate
+		for _, ref := range References {
+			from, to := before.factorValue(ref.ScalingFactor), after.factorValue(ref.ScalingFactor)
+			for _, point := range ref.ReferencePoints {
+				if point.Value <= from || point.Value > to {
+					continue
+				}
+				c := ReferenceCrossing{Month: p.Points[i].Month, Service: ref.ServiceLabel, Factor: ref.ScalingFactor, Value: point.Value}
+				if _, ok := crossed[c]; !ok {
+					crossed[c] = struct{}{}
+					p.Crossings = append(p.Crossings, c)
+				}
+			}
+		}
+	}
+	sort.SliceStable(p.Crossings, func(i, j int) bool {
+		if p.Crossings[i].Month != p.Crossings[j].Month {
+			return p.Crossings[i].Month < p.Crossings[j].Month
+		}
+		return p.Crossings[i].Service < p.Crossings[j].Service
+	})
+
+	now := p.Points[0].Estimate
+	for _, name := range pvcServices {
+		current, ok := now.Services[name]
+		if !ok || current.Storage == 0 {
+			continue
+		}
+		replicas := math.Max(float64(current.Replicas), 1)
+		recommended := current.Storage
+		for _, point := range p.Points {
+			v := point.Estimate.Services[name]
+			// Keep the current number of replicas, each holding its share of the data.
+			recommended = math.Max(recommended, math.Ceil(v.Storage*math.Max(float64(v.Replicas), 1)/replicas))
+		}
+		p.PVCs = append(p.PVCs, PVCRecommendation{Service: current.Label, Now: current.Storage, Recommended: recommended})
+	}
+	return p
+}
@@ -0,0 +1,108 @@
+package scaling
+
+import "math"
+
+const (
+	// zoekt indexes the default branch only, whose source is roughly half the size of the git
+	// repository, and the index is roughly 120% of the size of that source.
+	zoektSourceRatio = 0.5
+	zoektIndexRatio  = 1.2
+
+	// zoekt splits the index of a repository into shards of up to 100 MB.
+	zoektShardSizeMB = 100
+
+	// Most of the index is memory mapped and served from the page cache. What stays resident is
+	// the ngram and posting list metadata (about 1% of the index) plus a fixed cost per shard.
+	zoektResidentRatio   = 0.01
+	zoektShardOverheadMB = 0.05
+
+	// Bounds of a single zoekt-webserver replica: the smallest memory request we recommend, and
+	// how much it should serve before the index is split across more replicas.
+	zoektMinMemoryGB           = 2
+	zoektMaxMemoryPerReplicaGB = 32
+	zoektMaxShardsPerReplica   = 100000
+
+	// zoekt-indexserver indexes about 2 MB of source per core per second, and we expect about a
+	// fifth of the corpus to be reindexed every day.
+	zoektIndexMBPerCoreSecond = 2
+	zoektDailyReindexRatio    = 0.2
+)
+
+// IndexedSearchPlan describes the size of the zoekt index and how it is spread across
+// indexed-search replicas.
+type IndexedSearchPlan struct {
+	// IndexSize is the total size of the index in GB.
+	IndexSize float64
+	Shards    int
+	// MemoryGB is the resident memory needed to serve the whole index, across all replicas.
+	MemoryGB float64
+	Replicas int
+	// ShardBound is true when the replica count is set by the number of shards rather than by
+	// memory, and LoadBound when it is set by the search load.
+	ShardBound, LoadBound bool
+	// ReindexCPU is the number of cores needed to keep up with reindexing, across all replicas.
+	ReindexCPU float64
+}
+
+// planIndexedSearch sizes indexed-search from the size of the index rather than the number of
+// repositories: 100k tiny repositories and 5k huge ones need very different amounts of memory.
+// zoekt-webserver memory and the replica count of the indexed-search pod follow the index size
+// and shard count, and zoekt-indexserver CPU follows the expected reindex throughput.
+func (e *Estimate) planIndexedSearch() {
+	webserver, ok := e.Services["indexedSearchIndexer"]
+	if !ok {
+		return
+	}
+	indexserver := e.Services["indexedSearch"]
+
+	indexGB := float64(e.TotalRepoSize) * zoektSourceRatio * zoektIndexRatio
+	largestIndexGB := float64(e.LargestRepoSize) * zoektSourceRatio * zoektIndexRatio
+	plan := IndexedSearchPlan{
+		IndexSize: indexGB,
+		Shards:    e.zoektShards(indexGB),
+	}
+	plan.MemoryGB = indexGB*zoektResidentRatio + float64(plan.Shards)*zoektShardOverheadMB/1000
+
+	// All shards of a repository are served by the same replica, so every replica must be able
+	// to hold the largest repository on top of its share.
+	largestMemoryGB := largestIndexGB * zoektResidentRatio
+	byMemory := math.Ceil(plan.MemoryGB / math.Max(zoektMaxMemoryPerReplicaGB-largestMemoryGB, 1))
+	byShards := math.Ceil(float64(plan.Shards) / zoektMaxShardsPerReplica)
+	byLoad := float64(webserver.Replicas)
+	plan.Replicas = int(math.Max(math.Max(math.Max(byMemory, byShards), byLoad), 1))
+	plan.ShardBound = byShards > byMemory && byShards >= byLoad
+	plan.LoadBound = byLoad > byMemory && byLoad > byShards
+
+	replicas := float64(plan.Replicas)
+	memory := math.Max(plan.MemoryGB/replicas+largestMemoryGB, zoektMinMemoryGB)
+	webserver.Replicas = plan.Replicas
+	webserver.setMemory(math.Ceil(memory), math.Ceil(memory)*2)
+	webserver.sizedBy(ByTotalRepoSize)
+	e.Services["indexedSearchIndexer"] = webserver
+
+	sourceMB := float64(e.TotalRepoSize) * zoektSourceRatio * 1000
+	plan.ReindexCPU = sourceMB * zoektDailyReindexRatio / (zoektIndexMBPerCoreSecond * 24 * 60 * 60)
+	if cpu := math.Ceil(plan.ReindexCPU / replicas); cpu > indexserver.Resources.Requests.CPU {
+		indexserver.setCPU(cpu, math.Max(indexserver.Resources.Limits.CPU, cpu*2))
+	}
+	indexserver.Replicas = plan.Replicas
+	indexserver.sizedBy(ByTotalRepoSize)
+	indexserver.setStorage(math.Ceil(indexGB/replicas + largestIndexGB))
+	e.Services["indexedSearch"] = indexserver
+
+	e.IndexedSearch = plan
+}
+
+// zoektShards returns the number of shards of an index of the given size in GB. Every repository
+// has at least one shard, and large repositories are split into several.
+func (e *Estimate) zoektShards(indexGB float64) int {
+	if e.RepoSizeDistribution == nil {
+		return int(math.Max(float64(e.Repositories), math.Ceil(indexGB*1000/zoektShardSizeMB)))
+	}
+	var shards int
+	for _, s := range e.RepoSizeDistribution.sizes(e.Repositories, e.TotalRepoSize) {
+		repoIndexMB := s.SizeGB * zoektSourceRatio * zoektIndexRatio * 1000
+		shards += s.Count * int(math.Max(math.Ceil(repoIndexMB/zoektShardSizeMB), 1))
+	}
+	return shards
+}
@@ -0,0 +1,93 @@
+package scaling
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// RepositoryCode describes the code of a repository, used to predict the size of its SCIP index.
+type RepositoryCode struct {
+	Name        string `json:",omitempty"`
+	LinesOfCode int
+	Language    string // primary language, such as "go" or "typescript"
+}
+
+// scipIndexer is the SCIP indexer of a language, and the range of bytes its indexes take per line
+// of code.
+type scipIndexer struct {
+	Name                           string
+	MinBytesPerLOC, MaxBytesPerLOC float64
+}
+
+// scipIndexers are the SCIP indexers by language. The ranges are observed on open source
+// repositories; code with many cross references (C++ headers, generated code) is at the high end.
+var scipIndexers = map[string]scipIndexer{
+	"go":         {"scip-go", 150, 300},
+	"java":       {"scip-java", 200, 400},
+	"kotlin":     {"scip-java", 200, 400},
+	"scala":      {"scip-java", 250, 500},
+	"typescript": {"scip-typescript", 200, 500},
+	"javascript": {"scip-typescript", 150, 400},
+	"python":     {"scip-python", 150, 350},
+	"ruby":       {"scip-ruby", 100, 250},
+	"rust":       {"rust-analyzer", 200, 400},
+	"c":          {"scip-clang", 300, 800},
+	"c++":        {"scip-clang", 400, 1000},
+	"c#":         {"scip-dotnet", 200, 400},
+}
+
+// unknownIndexer is used for languages without a known indexer, with the widest range.
+var unknownIndexer = scipIndexer{"unknown indexer", 100, 1000}
+
+func indexerFor(language string) scipIndexer {
+	if indexer, ok := scipIndexers[strings.ToLower(strings.TrimSpace(language))]; ok {
+		return indexer
+	}
+	return unknownIndexer
+}
+
+// IndexSizePrediction is the predicted size of the SCIP index of a repository.
+type IndexSizePrediction struct {
+	Repository   RepositoryCode
+	Indexer      string
+	MinGB, MaxGB float64
+}
+
+// predictIndexSize predicts the size of the SCIP indexes of the repositories, and raises the
+// largest index size to the high end of the largest prediction so that precise code intel is
+// not undersized. A larger given index size is kept.
+func (e *Estimate) predictIndexSize() {
+	e.PredictedIndexSizes = nil
+	for _, repo := range e.IndexSizeRepositories {
+		if repo.LinesOfCode <= 0 {
+			continue
+		}
+		indexer := indexerFor(repo.Language)
+		e.PredictedIndexSizes = append(e.PredictedIndexSizes, IndexSizePrediction{
+			Repository: repo,
+			Indexer:    indexer.Name,
+			MinGB:      float64(repo.LinesOfCode) * indexer.MinBytesPerLOC / 1e9,
+			MaxGB:      float64(repo.LinesOfCode) * indexer.MaxBytesPerLOC / 1e9,
+		})
+	}
+	if len(e.PredictedIndexSizes) == 0 {
+		return
+	}
+	sort.SliceStable(e.PredictedIndexSizes, func(i, j int) bool {
+		return e.PredictedIndexSizes[i].MaxGB > e.PredictedIndexSizes[j].MaxGB
+	})
+	predicted := int(math.Max(math.Ceil(e.PredictedIndexSizes[0].MaxGB), LargestIndexSizeRange.Min))
+	if predicted > e.LargestIndexSize {
+		e.LargestIndexSize = predicted
+	}
+}
+
+// indexSizePredicted reports whether the largest index size is the one predicted, rather than a
+// larger given one.
+func (e *Estimate) indexSizePredicted() bool {
+	if len(e.PredictedIndexSizes) == 0 {
+		return false
+	}
+	return float64(e.LargestIndexSize) <= math.Max(math.Ceil(e.PredictedIndexSizes[0].MaxGB), LargestIndexSizeRange.Min)
+}
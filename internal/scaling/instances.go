@@ -0,0 +1,151 @@
+package scaling
+
+import "math"
+
+// InstanceType is a cloud provider virtual machine type.
+type InstanceType struct {
+	Provider, Name string
+	CPU, MEM       float64
+	// On-demand list price in USD per hour.
+	HourlyCost float64
+}
+
+// DiskType is a cloud provider block storage volume type.
+type DiskType struct {
+	Provider, Name string
+	MaxSizeGB      float64
+	// List price in USD per GB per month.
+	MonthlyCostPerGB float64
+}
+
+// Providers lists the cloud providers in the instance catalog, in display order.
+var Providers = []string{"AWS", "GCP", "Azure"}
+
+// instanceTypes is an offline catalog of general purpose, memory optimized and compute optimized
+// instance types. Prices are on-demand list prices in us-east-1 / us-central1 / East US.
+var instanceTypes = []InstanceType{
+	{Provider: "AWS", Name: "m6i.2xlarge", CPU: 8, MEM: 32, HourlyCost: 0.384},
+	{Provider: "AWS", Name: "m6i.4xlarge", CPU: 16, MEM: 64, HourlyCost: 0.768},
+	{Provider: "AWS", Name: "m6i.8xlarge", CPU: 32, MEM: 128, HourlyCost: 1.536},
+	{Provider: "AWS", Name: "m6i.12xlarge", CPU: 48, MEM: 192, HourlyCost: 2.304},
+	{Provider: "AWS", Name: "m6i.16xlarge", CPU: 64, MEM: 256, HourlyCost: 3.072},
+	{Provider: "AWS", Name: "m6i.24xlarge", CPU: 96, MEM: 384, HourlyCost: 4.608},
+	{Provider: "AWS", Name: "m6i.32xlarge", CPU: 128, MEM: 512, HourlyCost: 6.144},
+	{Provider: "AWS", Name: "r6i.2xlarge", CPU: 8, MEM: 64, HourlyCost: 0.504},
+	{Provider: "AWS", Name: "r6i.4xlarge", CPU: 16, MEM: 128, HourlyCost: 1.008},
+	{Provider: "AWS", Name: "r6i.8xlarge", CPU: 32, MEM: 256, HourlyCost: 2.016},
+	{Provider: "AWS", Name: "r6i.12xlarge", CPU: 48, MEM: 384, HourlyCost: 3.024},
+	{Provider: "AWS", Name: "r6i.16xlarge", CPU: 64, MEM: 512, HourlyCost: 4.032},
+	{Provider: "AWS", Name: "r6i.24xlarge", CPU: 96, MEM: 768, HourlyCost: 6.048},
+	{Provider: "AWS", Name: "r6i.32xlarge", CPU: 128, MEM: 1024, HourlyCost: 8.064},
+	{Provider: "AWS", Name: "c6i.4xlarge", CPU: 16, MEM: 32, HourlyCost: 0.68},
+	{Provider: "AWS", Name: "c6i.8xlarge", CPU: 32, MEM: 64, HourlyCost: 1.36},
+	{Provider: "AWS", Name: "c6i.12xlarge", CPU: 48, MEM: 96, HourlyCost: 2.04},
+	{Provider: "AWS", Name: "c6i.16xlarge", CPU: 64, MEM: 128, HourlyCost: 2.72},
+	{Provider: "AWS", Name: "c6i.24xlarge", CPU: 96, MEM: 192, HourlyCost: 4.08},
+	{Provider: "AWS", Name: "c6i.32xlarge", CPU: 128, MEM: 256, HourlyCost: 5.44},
+
+	{Provider: "GCP", Name: "n2-standard-8", CPU: 8, MEM: 32, HourlyCost: 0.3885},
+	{Provider: "GCP", Name: "n2-standard-16", CPU: 16, MEM: 64, HourlyCost: 0.777},
+	{Provider: "GCP", Name: "n2-standard-32", CPU: 32, MEM: 128, HourlyCost: 1.554},
+	{Provider: "GCP", Name: "n2-standard-48", CPU: 48, MEM: 192, HourlyCost: 2.331},
+	{Provider: "GCP", Name: "n2-standard-64", CPU: 64, MEM: 256, HourlyCost: 3.108},
+	{Provider: "GCP", Name: "n2-standard-80", CPU: 80, MEM: 320, HourlyCost: 3.885},
+	{Provider: "GCP", Name: "n2-standard-96", CPU: 96, MEM: 384, HourlyCost: 4.662},
+	{Provider: "GCP", Name: "n2-standard-128", CPU: 128, MEM: 512, HourlyCost: 6.216},
+	{Provider: "GCP", Name: "n2-highmem-8", CPU: 8, MEM: 64, HourlyCost: 0.524},
+	{Provider: "GCP", Name: "n2-highmem-16", CPU: 16, MEM: 128, HourlyCost: 1.048},
+	{Provider: "GCP", Name: "n2-highmem-32", CPU: 32, MEM: 256, HourlyCost: 2.096},
+	{Provider: "GCP", Name: "n2-highmem-48", CPU: 48, MEM: 384, HourlyCost: 3.144},
+	{Provider: "GCP", Name: "n2-highmem-64", CPU: 64, MEM: 512, HourlyCost: 4.192},
+	{Provider: "GCP", Name: "n2-highmem-80", CPU: 80, MEM: 640, HourlyCost: 5.24},
+	{Provider: "GCP", Name: "n2-highmem-96", CPU: 96, MEM: 768, HourlyCost: 6.288},
+	{Provider: "GCP", Name: "n2-highmem-128", CPU: 128, MEM: 864, HourlyCost: 7.7},
+	{Provider: "GCP", Name: "n2-highcpu-16", CPU: 16, MEM: 16, HourlyCost: 0.574},
+	{Provider: "GCP", Name: "n2-highcpu-32", CPU: 32, MEM: 32, HourlyCost: 1.147},
+	{Provider: "GCP", Name: "n2-highcpu-48", CPU: 48, MEM: 48, HourlyCost: 1.721},
+	{Provider: "GCP", Name: "n2-highcpu-64", CPU: 64, MEM: 64, HourlyCost: 2.294},
+	{Provider: "GCP", Name: "n2-highcpu-96", CPU: 96, MEM: 96, HourlyCost: 3.441},
+
+	{Provider: "Azure", Name: "Standard_D8s_v5", CPU: 8, MEM: 32, HourlyCost: 0.384},
+	{Provider: "Azure", Name: "Standard_D16s_v5", CPU: 16, MEM: 64, HourlyCost: 0.768},
+	{Provider: "Azure", Name: "Standard_D32s_v5", CPU: 32, MEM: 128, HourlyCost: 1.536},
+	{Provider: "Azure", Name: "Standard_D48s_v5", CPU: 48, MEM: 192, HourlyCost: 2.304},
+	{Provider: "Azure", Name: "Standard_D64s_v5", CPU: 64, MEM: 256, HourlyCost: 3.072},
+	{Provider: "Azure", Name: "Standard_D96s_v5", CPU: 96, MEM: 384, HourlyCost: 4.608},
+	{Provider: "Azure", Name: "Standard_E8s_v5", CPU: 8, MEM: 64, HourlyCost: 0.504},
+	{Provider: "Azure", Name: "Standard_E16s_v5", CPU: 16, MEM: 128, HourlyCost: 1.008},
+	{Provider: "Azure", Name: "Standard_E32s_v5", CPU: 32, MEM: 256, HourlyCost: 2.016},
+	{Provider: "Azure", Name: "Standard_E48s_v5", CPU: 48, MEM: 384, HourlyCost: 3.024},
+	{Provider: "Azure", Name: "Standard_E64s_v5", CPU: 64, MEM: 512, HourlyCost: 4.032},
+	{Provider: "Azure", Name: "Standard_E96s_v5", CPU: 96, MEM: 672, HourlyCost: 6.048},
+	{Provider: "Azure", Name: "Standard_F16s_v2", CPU: 16, MEM: 32, HourlyCost: 0.677},
+	{Provider: "Azure", Name: "Standard_F32s_v2", CPU: 32, MEM: 64, HourlyCost: 1.353},
+	{Provider: "Azure", Name: "Standard_F48s_v2", CPU: 48, MEM: 96, HourlyCost: 2.03},
+	{Provider: "Azure", Name: "Standard_F64s_v2", CPU: 64, MEM: 128, HourlyCost: 2.706},
+	{Provider: "Azure", Name: "Standard_F72s_v2", CPU: 72, MEM: 144, HourlyCost: 3.045},
+}
+
+// diskTypes is an offline catalog of SSD-backed block storage volume types. gitserver and
+// zoekt are too latency sensitive to run on HDD-backed volumes.
+var diskTypes = []DiskType{
+	{Provider: "AWS", Name: "gp3", MaxSizeGB: 16384, MonthlyCostPerGB: 0.08},
+	{Provider: "AWS", Name: "io2", MaxSizeGB: 65536, MonthlyCostPerGB: 0.125},
+	{Provider: "GCP", Name: "pd-balanced", MaxSizeGB: 65536, MonthlyCostPerGB: 0.10},
+	{Provider: "GCP", Name: "pd-ssd", MaxSizeGB: 65536, MonthlyCostPerGB: 0.17},
+	{Provider: "Azure", Name: "Premium SSD v2", MaxSizeGB: 65536, MonthlyCostPerGB: 0.082},
+	{Provider: "Azure", Name: "Premium SSD", MaxSizeGB: 32767, MonthlyCostPerGB: 0.135},
+}
+
+// hoursPerMonth is the average number of hours in a month, as used by cloud provider pricing.
+const hoursPerMonth = 730
+
+// InstanceRecommendation is the cheapest instance and data disk of a provider that fit the
+// estimate.
+type InstanceRecommendation struct {
+	Provider string
+	// Instance is the zero value when no instance type of the provider is large enough.
+	Instance InstanceType
+	// Candidates is the number of instance types that are large enough.
+	Candidates int
+	Disk       DiskType
+	// Disks is the number of data disks needed when the storage exceeds the maximum disk size.
+	Disks       int
+	MonthlyCost float64
+}
+
+// InstanceRecommendations picks, for every provider, the cheapest instance type with at least
+// the estimated vCPUs and memory, and the disk type that holds the estimated storage at the
+// lowest monthly cost, with the fewest disks on a tie.
+func (e *Estimate) InstanceRecommendations() []InstanceRecommendation {
+	var recommendations []InstanceRecommendation
+	for _, provider := range Providers {
+		r := InstanceRecommendation{Provider: provider}
+		for _, it := range instanceTypes {
+			if it.Provider != provider || it.CPU < float64(e.TotalCPU) || it.MEM < float64(e.TotalMemoryGB) {
+				continue
+			}
+			r.Candidates++
+			if r.Instance.Name == "" || it.HourlyCost < r.Instance.HourlyCost {
+				r.Instance = it
+			}
+		}
+		storage := math.Max(float64(e.TotalStorageSize), 1)
+		for _, dt := range diskTypes {
+			if dt.Provider != provider {
+				continue
+			}
+			// The storage is split evenly across the disks, so the disks cost the storage at the
+			// price of the disk type, whatever their number.
+			disks := int(math.Ceil(storage / dt.MaxSizeGB))
+			cost, best := storage*dt.MonthlyCostPerGB, storage*r.Disk.MonthlyCostPerGB
+			if r.Disk.Name == "" || cost < best || (cost == best && disks < r.Disks) {
+				r.Disk = dt
+				r.Disks = disks
+			}
+		}
+		r.MonthlyCost = r.Instance.HourlyCost*hoursPerMonth + storage*r.Disk.MonthlyCostPerGB
+		recommendations = append(recommendations, r)
+	}
+	return recommendations
+}
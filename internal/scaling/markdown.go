@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/ghodss/yaml"
@@ -17,14 +19,25 @@ func (e *Estimate) MarkdownExport() []byte {
 	fmt.Fprintf(&buf, "\n")
 	if e.ContactSupport {
 		fmt.Fprintf(&buf, "**Estimation is currently not available for your instance size. Please [contact support](mailto:support@sourcegraph.com) for further assists.**\n")
+		if plan := e.GitserverShards; plan.Shards > 0 && !plan.Fits {
+			fmt.Fprintf(&buf, "\nThe room for the largest repositories on a gitserver shard, %vg, exceeds the maximum volume size of %vg.\n", plan.Headroom, e.GitserverMaxVolumeSize)
+		}
 	} else {
 		fmt.Fprintf(&buf, "* **Instance Size:** %v\n", e.InstanceSize)
 		fmt.Fprintf(&buf, "* **Estimated vCPUs:** %v\n", e.TotalCPU)
 		fmt.Fprintf(&buf, "* **Estimated Memory:** %vg\n", e.TotalMemoryGB)
 		fmt.Fprintf(&buf, "* **Estimated Minimum Volume Size:** %vg\n", e.TotalStorageSize)
 		fmt.Fprintf(&buf, "* **Recommend Deployment Type:** [%v](https://docs.sourcegraph.com/admin/deploy#deployment-types)\n", e.RecommendedDeploymentType)
+		if len(e.PredictedIndexSizes) > 0 {
+			largest := e.PredictedIndexSizes[0]
+			source := "predicted"
+			if !e.indexSizePredicted() {
+				source = "given"
+			}
+			fmt.Fprintf(&buf, "* **Predicted Largest SCIP Index:** %.1fg to %.1fg, sized for the %v %vg\n", largest.MinGB, largest.MaxGB, source, e.LargestIndexSize)
+		}
 
-		fmt.Fprintf(&buf, "\n<small>**Note:** The estimated values include default values for services that are not listed in the estimator, like otel-collector and repo-updater for example. The default values for the non-displaying services should work well with instances of all sizes.</small>\n")
+		fmt.Fprintf(&buf, "\n<small>**Note:** The estimated values include default values for services that are not listed in the estimator, like cadvisor and repo-updater for example. The default values for the non-displaying services should work well with instances of all sizes.</small>\n")
 		if e.EngagedUsers < 650/2 && e.AverageRepositories < 1500/2 {
 			//nolint:staticcheck
 			if e.DeploymentType == "docker-compose" {
@@ -53,9 +66,12 @@ func (e *Estimate) MarkdownExport() []byte {
 		}
 		fmt.Fprintf(&buf, "\n")
 		fmt.Fprintf(&buf, "\n")
+		if e.RecommendedDeploymentType == "Sourcegraph Machine Images" {
+			e.markdownInstanceTypes(&buf)
+		}
 
-		fmt.Fprintf(&buf, "| Service | Replica | CPU requests | CPU limits | MEM requests | MEM limits  | Storage |\n")
-		fmt.Fprintf(&buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+		fmt.Fprintf(&buf, "| Service | Replica | CPU requests | CPU limits | MEM requests | MEM limits  | Storage | Sized by |\n")
+		fmt.Fprintf(&buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|-------|\n")
 
 		var names []string
 		for service := range e.Services {
@@ -129,9 +145,13 @@ func (e *Estimate) MarkdownExport() []byte {
 					}
 				}
 			}
+			var sizedBy []string
+			for _, f := range ref.SizedBy {
+				sizedBy = append(sizedBy, f.String())
+			}
 			fmt.Fprintf(
 				&buf,
-				"| %v | %v | %v | %v | %v | %v | %v |\n",
+				"| %v | %v | %v | %v | %v | %v | %v | %v |\n",
 				serviceName,
 				replicas,
 				cpuRequest,
@@ -139,20 +159,510 @@ func (e *Estimate) MarkdownExport() []byte {
 				memoryGBRequest,
 				memoryGBLimit,
 				pvc,
+				strings.Join(sizedBy, ", "),
 			)
 		}
 		fmt.Fprintf(&buf, "\n")
 		fmt.Fprintf(&buf, "> ꜝ<small> This is a non-default value.</small>\n")
 		fmt.Fprintf(&buf, "\n")
 		fmt.Fprintf(&buf, "\n")
+		e.markdownUserLoad(&buf)
+		if e.RepoSizeDistribution != nil {
+			e.markdownRepoSizeDistribution(&buf)
+		}
+		if e.DeploymentType == "kubernetes" {
+			e.markdownGitserverShards(&buf)
+		}
+		e.markdownIndexedSearch(&buf)
+		e.markdownRollout(&buf)
+		e.markdownStorage(&buf)
+		if len(e.PredictedIndexSizes) > 0 {
+			e.markdownIndexSizePrediction(&buf)
+		}
+		e.markdownCodeIntel(&buf)
+		e.markdownDatabases(&buf)
+		e.markdownObservability(&buf)
+		if hpas := e.HorizontalPodAutoscalers(); e.DeploymentType == "kubernetes" && len(hpas) > 0 {
+			e.markdownAutoscaling(&buf, hpas)
+		}
+		if e.HighAvailability {
+			e.markdownHighAvailability(&buf)
+		}
+		e.markdownBackups(&buf)
+		if !e.staging {
+			e.markdownStaging(&buf)
+		}
+		if p := e.Projection(); p != nil {
+			e.markdownProjection(&buf, p)
+		}
 	}
 	return buf.Bytes()
 
 }
 
+func (e *Estimate) markdownInstanceTypes(buf *bytes.Buffer) {
+	recommendations := e.InstanceRecommendations()
+	fmt.Fprintf(buf, "#### Recommended cloud instance types\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Provider | Instance type | vCPUs | Memory | Data disk | Est. monthly cost |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+	var candidates []string
+	for _, r := range recommendations {
+		candidates = append(candidates, fmt.Sprintf("%v on %v", r.Candidates, r.Provider))
+		disk := fmt.Sprint(e.TotalStorageSize, "g ", r.Disk.Name)
+		if r.Disks > 1 {
+			disk = fmt.Sprint(r.Disks, " × ", r.Disk.MaxSizeGB, "g ", r.Disk.Name)
+		}
+		if r.Instance.Name == "" {
+			fmt.Fprintf(buf, "| %v | n/a | n/a | n/a | %v | n/a |\n", r.Provider, disk)
+			continue
+		}
+		fmt.Fprintf(buf, "| %v | %v | %v | %vg | %v | ~$%.0f |\n", r.Provider, r.Instance.Name, r.Instance.CPU, r.Instance.MEM, disk, r.MonthlyCost)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Each instance type is the cheapest one in the bundled catalog with at least %v vCPUs and %vg memory (candidates: %v). The data disk is the cheapest SSD-backed volume type that can hold %vg. Costs are based on on-demand list prices and may be outdated.</small>\n", e.TotalCPU, e.TotalMemoryGB, strings.Join(candidates, ", "), e.TotalStorageSize)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownUserLoad(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "#### User load\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Engaged users:** %v of %v users\n", e.EngagedUsers, e.Users)
+	fmt.Fprintf(buf, "* **Peak concurrent users:** %v (%v%% of engaged users)\n", e.PeakUsers, e.peakConcurrency())
+	if load := e.searchLoad(); load > 0 {
+		profile := ""
+		if e.AutomationHeavy {
+			profile = fmt.Sprintf(" (%.0f QPS of automation heavy traffic, weighted %v times)", load/automationQueryCost, automationQueryCost)
+		}
+		fmt.Fprintf(buf, "* **Peak search load:** %.0f QPS%v\n", load, profile)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Engaged users are the users who use Sourcegraph regularly, and they determine the instance size. sourcegraph-frontend, redis-store and syntect-server scale with the peak load: the engaged users, adjusted for a peak concurrency of %v%% of them.</small>\n", e.peakConcurrency())
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownRepoSizeDistribution(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "#### Repository size distribution\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Repositories:** %v\n", e.Repositories)
+	fmt.Fprintf(buf, "* **Total size:** %vg\n", e.TotalRepoSize)
+	fmt.Fprintf(buf, "* **Largest repository:** %vg\n", e.LargestRepoSize)
+	fmt.Fprintf(buf, "* **Monorepos (larger than %vg):** %v\n", monorepoSizeGB, e.LargeMonorepos)
+	fmt.Fprintf(buf, "* **Effective repositories:** %v\n", e.AverageRepositories)
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** These values are derived from the repository size distribution. Repositories in a histogram bucket are assumed to be half way between its bounds, and every additional %vg of a monorepo counts as one more repository. searcher and symbols keep room for the %v largest repositories, and zoekt shards follow the size of each repository.</small>\n", monorepoSizeGB, hotRepositories)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownGitserverShards(buf *bytes.Buffer) {
+	plan := e.GitserverShards
+	fmt.Fprintf(buf, "#### gitserver shards\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Shards (gitserver replicas):** %v\n", plan.Shards)
+	if plan.Headroom > float64(e.LargestRepoSize) {
+		fmt.Fprintf(buf, "* **Volume size per shard:** %vg (an even share of the repositories plus %vg for the largest repositories that may land on the same shard)\n", plan.PVCSize, plan.Headroom)
+	} else {
+		fmt.Fprintf(buf, "* **Volume size per shard:** %vg (an even share of the repositories plus %vg for the largest repository)\n", plan.PVCSize, e.LargestRepoSize)
+	}
+	if e.GitserverMaxVolumeSize > 0 {
+		fmt.Fprintf(buf, "* **Maximum volume size:** %vg\n", e.GitserverMaxVolumeSize)
+		if plan.Shards > plan.RepositoryShards {
+			fmt.Fprintf(buf, "* **Shards for the repository count alone:** %v (raised to stay within the maximum volume size)\n", plan.RepositoryShards)
+		}
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Rebalancing:** Repositories are assigned to shards by hashing their names. Adding shards later moves roughly 1/N of the repositories to the new shards, where they must be cloned again, so prefer provisioning the planned number of shards up front.</small>\n")
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownIndexedSearch(buf *bytes.Buffer) {
+	plan := e.IndexedSearch
+	if plan.Replicas == 0 {
+		return
+	}
+	bound := "resident memory"
+	if plan.ShardBound {
+		bound = "shard count"
+	} else if plan.LoadBound {
+		bound = "search load"
+	}
+	fmt.Fprintf(buf, "#### Indexed search\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Index size:** %.0fg in %v shards\n", plan.IndexSize, plan.Shards)
+	fmt.Fprintf(buf, "* **Resident memory:** %.1fg across all replicas, plus room for the largest repository on each replica\n", plan.MemoryGB)
+	fmt.Fprintf(buf, "* **Replicas:** %v (set by the %v)\n", plan.Replicas, bound)
+	fmt.Fprintf(buf, "* **Reindex throughput:** %.1f vCPUs across all replicas\n", plan.ReindexCPU)
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** zoekt memory tracks the size of the index rather than the number of repositories. The index is estimated at %v%% of the total repository size, split into shards of up to %vMB, and each zoekt-webserver replica serves up to %vg of resident memory or %v shards. zoekt-indexserver CPU covers reindexing %v%% of the corpus every day.</small>\n", zoektSourceRatio*zoektIndexRatio*100, zoektShardSizeMB, zoektMaxMemoryPerReplicaGB, zoektMaxShardsPerReplica, zoektDailyReindexRatio*100)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownRollout(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "#### Initial rollout\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| gitserver replicas | indexed-search replicas | All repositories cloned | All repositories searchable |\n")
+	fmt.Fprintf(buf, "|:-------:|:-------:|:-------:|:-------:|\n")
+	for i, o := range e.Rollout.Options {
+		current := ""
+		if i == 0 {
+			current = " (estimate)"
+		}
+		fmt.Fprintf(buf, "| %v%v | %v%v | %v | %v |\n", o.GitserverReplicas, current, o.IndexedSearchReplicas, current, formatDuration(o.Cloned), formatDuration(o.Searchable))
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Each gitserver replica clones its share of the repositories at %vMB/s, the lower of the network throughput from the code hosts (%vMB/s) and the disk throughput (%vMB/s), and up to %v repositories at a time with %vs of overhead each. Repositories are searchable once zoekt-indexserver, at %vMB of source per core per second, has indexed them. Code host rate limits are not taken into account.</small>\n", math.Min(e.cloneThroughputMBps(), e.diskThroughputMBps()), e.cloneThroughputMBps(), e.diskThroughputMBps(), cloneConcurrency, cloneOverheadSeconds, zoektIndexMBPerCoreSecond)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownStorage(buf *bytes.Buffer) {
+	plan := e.Storage
+	fmt.Fprintf(buf, "#### Storage performance\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Volume | IOPS | Throughput | Class | Storage class |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, v := range plan.Volumes {
+		name := v.Service
+		if label := e.Services[v.Service].Label; label != "" {
+			name = label
+		}
+		fmt.Fprintf(buf, "| **%v** | %v | %vMB/s | %v | %v |\n", name, v.IOPS, v.ThroughputMBps, v.Class, v.StorageClass)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Fetches from the code hosts:** %.2f per second, every %v minutes on average\n", plan.FetchesPerSecond, e.repoFetchIntervalMinutes())
+	fmt.Fprintf(buf, "* **Egress from the code hosts:** %.1fg a day, %.1fMbps on average, once the %vg of repositories are cloned\n", plan.EgressGBPerDay, plan.EgressGBPerDay*8*1000/(24*60*60), e.TotalRepoSize)
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** IOPS and throughput are per volume, with the load spread across the replicas. Volumes up to %v IOPS and %vMB/s can use standard disks (standard); up to %v IOPS and %vMB/s they need SSDs (ssd), and beyond that SSDs with provisioned IOPS or throughput (provisioned). A fetch transfers about %v%% of the repository, as most find few new commits, and searcher reads the archive of the repository again after about %v%% of the fetches. zoekt serves searches from the page cache, so its volume only sees a baseline of %v IOPS besides reindexing. The storage class is set in the Helm export as storageClassName; map it to the classes of your cluster.</small>\n", volumeClasses[0].IOPS, volumeClasses[0].MBps, volumeClasses[1].IOPS, volumeClasses[1].MBps, fetchDeltaRatio*100, fetchArchiveRatio*100, zoektBaseIOPS)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownIndexSizePrediction(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "#### SCIP index size\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Repository | Lines of code | Language | Indexer | Index size |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, p := range e.PredictedIndexSizes {
+		name := p.Repository.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(buf, "| %v | %v | %v | %v | %.1fg to %.1fg |\n", name, p.Repository.LinesOfCode, p.Repository.Language, p.Indexer, p.MinGB, p.MaxGB)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** The size of a SCIP index is predicted from the lines of code of the repository and the bytes per line of code the indexer of its language produces, from the simplest code to code with many cross references. Precise code intel is sized for the larger of the high end of the largest index and the given largest index size, %vg.</small>\n", e.LargestIndexSize)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownCodeIntel(buf *bytes.Buffer) {
+	plan := e.CodeIntel
+	if plan.UploadsPerDay == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "#### Precise code intel\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **Uploads:** %.0f a day across %v, %.2fg on average\n", plan.UploadsPerDay, plural(e.scipLanguages(), "language", "languages"), plan.AverageIndexGB)
+	fmt.Fprintf(buf, "* **Uploads kept:** %.0fg for %v days\n", math.Ceil(plan.RetainedGB), e.scipRetentionDays())
+	fmt.Fprintf(buf, "* **blobstore:** %.0fg\n", plan.BlobstoreGB)
+	if plan.Workers > 0 {
+		fmt.Fprintf(buf, "* **precise-code-intel-worker:** %v replicas to process the uploads at peak\n", plan.Workers)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Every indexed commit is uploaded once per language. The uploads kept are the tip of the default branch for every language, and every upload made during the retention period. Without an upload volume, one upload of the largest index is assumed a day, and blobstore holds the largest index. Otherwise blobstore holds the uploads of the retention period, and each precise-code-intel-worker replica processes %vg an hour at a peak of %v times the average upload rate.</small>\n", scipProcessGBPerWorkerHour, scipUploadPeakFactor)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownDatabases(buf *bytes.Buffer) {
+	plan := e.Databases
+	fmt.Fprintf(buf, "#### Databases\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "* **pgsql:** %.0fg for %v and %v\n", math.Ceil(plan.PgsqlGB), plural(e.AverageRepositories, "repository", "repositories"), plural(e.Users, "user", "users"))
+	if plan.CodeIntelGB > 0 {
+		fmt.Fprintf(buf, "* **codeintel-db:** %.0fg for %.0fg of SCIP uploads kept for %v days\n", math.Ceil(plan.CodeIntelGB), math.Ceil(e.CodeIntel.RetainedGB), e.scipRetentionDays())
+	}
+	if plan.CodeInsightsGB > 0 {
+		fmt.Fprintf(buf, "* **codeinsights-db:** %.0fg for %v series over %v repositories each kept for %v months\n", math.Ceil(plan.CodeInsightsGB), e.insightSeries(), e.reposPerSeries(), e.insightsRetentionMonths())
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Database volumes are at least %vg, and %v times the size of the data to leave room for indexes, WAL and migrations. pgsql stores %vMB per repository and %vMB per user. codeintel-db stores the SCIP uploads kept, see Precise code intel. codeinsights-db stores %v bytes per series, repository and month of retention.</small>\n", minDatabaseStorageGB, databaseHeadroom, pgsqlMBPerRepository, pgsqlMBPerUser, insightsBytesPerDataPoint)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownObservability(buf *bytes.Buffer) {
+	plan := e.Observability
+	fmt.Fprintf(buf, "#### Observability\n")
+	fmt.Fprintf(buf, "\n")
+	switch e.observabilityMode() {
+	case ObservabilityDisabled:
+		fmt.Fprintf(buf, "* **Mode:** disabled, no monitoring or tracing components are deployed\n")
+	case ObservabilityExternal:
+		fmt.Fprintf(buf, "* **Mode:** external, metrics and traces are sent to your own stack and prometheus, grafana and jaeger are not deployed\n")
+	default:
+		fmt.Fprintf(buf, "* **Mode:** bundled\n")
+	}
+	if undeployed := e.undeployed(); len(undeployed) > 0 && e.DeploymentType == "docker-compose" {
+		fmt.Fprintf(buf, "* **Docker Compose:** the override file leaves out %v, which does not remove them: delete them from docker-compose.yaml\n", strings.Join(undeployed, ", "))
+	}
+	if e.observabilityMode() == ObservabilityDisabled {
+		fmt.Fprintf(buf, "\n")
+		return
+	}
+	series := "estimated"
+	if e.ActiveSeries > 0 {
+		series = "given"
+	}
+	fmt.Fprintf(buf, "* **Active series:** %v (%v, for %v replicas)\n", plan.Series, series, plan.Replicas)
+	fmt.Fprintf(buf, "* **Samples ingested:** %.0f per second, scraped every %vs\n", plan.SamplesPerSecond, plan.ScrapeIntervalSeconds)
+	if e.deployed("prometheus") {
+		fmt.Fprintf(buf, "* **prometheus:** %vg of metrics kept for %v days, %.1fg of memory\n", plan.PrometheusStorageGB, plan.RetentionDays, plan.PrometheusMemoryGB)
+	}
+	if e.TraceSamplingRate > 0 {
+		fmt.Fprintf(buf, "* **Traces:** %.0f spans per second at peak, %v%% of requests sampled\n", plan.SpansPerSecond, e.TraceSamplingRate)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Every replica exposes about %v series, plus %v for the monitoring stack and the hosts. prometheus stores %v bytes per sample with %v times the room for the WAL and compactions, keeps %vKB of memory per series plus %vg for queries, and ingests %v samples per second per vCPU. grafana uses %vg of memory per million series, and otel-collector %vMB per replica. A traced request has about %v spans, and jaeger keeps the spans of the last %v minutes in memory. Services are never sized below their defaults.</small>\n", seriesPerReplica, baseSeries, prometheusBytesPerSample, prometheusStorageHeadroom, prometheusKBPerSeries, prometheusBaseMemoryGB, prometheusSamplesPerCPUSecond, grafanaGBPerMillionSeries, otelCollectorMBPerReplica, spansPerRequest, jaegerRetentionSeconds/60)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownBackups(buf *bytes.Buffer) {
+	plan := e.Backups()
+	fmt.Fprintf(buf, "#### Backup and disaster recovery\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Volume | Size | Backup storage |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|\n")
+	for _, v := range plan.Volumes {
+		fmt.Fprintf(buf, "| **%v** | %vg | %vg |\n", v.Service, v.VolumeGB, v.BackupGB)
+	}
+	fmt.Fprintf(buf, "| **Total** | | %vg |\n", plan.TotalGB)
+	fmt.Fprintf(buf, "\n")
+	if site := e.StandbySite(); site != nil {
+		fmt.Fprintf(buf, "**Warm standby (%v):**\n", e.warmStandby())
+		fmt.Fprintf(buf, "\n")
+		fmt.Fprintf(buf, "| Site | Engaged users | vCPUs | Memory | Volume size | Backup storage |\n")
+		fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+		fmt.Fprintf(buf, "| Primary | %v | %v | %vg | %vg | %vg |\n", e.EngagedUsers, e.TotalCPU, e.TotalMemoryGB, e.TotalStorageSize, plan.TotalGB)
+		fmt.Fprintf(buf, "| Standby | %v | %v | %vg | %vg | %vg |\n", site.EngagedUsers, site.TotalCPU, site.TotalMemoryGB, site.TotalStorageSize, plan.TotalGB)
+		fmt.Fprintf(buf, "\n")
+	}
+	standby := ""
+	if e.warmStandby() != StandbyNone {
+		standby = " A warm-standby site holds all the data of the primary site and a copy of its backups."
+	}
+	if e.warmStandby() == StandbyReduced {
+		standby += fmt.Sprintf(" A reduced site serves %v%% of the engaged users without high availability, and is scaled up to the primary site after a failover.", standbyUserRatio*100)
+	}
+	fmt.Fprintf(buf, "<small>**Note:** Snapshots are taken every %v hours and %v are kept. The first snapshot is full, and the others keep the data changed since the previous one: %v.%v</small>\n", plan.FrequencyHours, plan.RetentionCount, backupChanges(), standby)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownStaging(buf *bytes.Buffer) {
+	staging := e.Staging()
+	fmt.Fprintf(buf, "#### Staging environment\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| | Production | Staging |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|\n")
+	fmt.Fprintf(buf, "| **Engaged users** | %v | %v |\n", e.EngagedUsers, staging.EngagedUsers)
+	fmt.Fprintf(buf, "| **Repositories** | %v | %v |\n", e.AverageRepositories, staging.AverageRepositories)
+	fmt.Fprintf(buf, "| **Repository size** | %vg | %vg |\n", e.TotalRepoSize, staging.TotalRepoSize)
+	fmt.Fprintf(buf, "| **vCPUs** | %v | %v |\n", e.TotalCPU, staging.TotalCPU)
+	fmt.Fprintf(buf, "| **Memory** | %vg | %vg |\n", e.TotalMemoryGB, staging.TotalMemoryGB)
+	fmt.Fprintf(buf, "| **Volume size** | %vg | %vg |\n", e.TotalStorageSize, staging.TotalStorageSize)
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Service class | Replicas | vCPU limits | Memory limits | Volumes |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, c := range e.StagingScales(staging) {
+		fmt.Fprintf(buf, "| **%v** | %v → %v | %v → %v (%v) | %vg → %vg (%v) | %vg → %vg (%v) |\n", c.Class,
+			c.Production.Replicas, c.Staging.Replicas,
+			math.Round(c.Production.CPU), math.Round(c.Staging.CPU), scaleDown(c.Production.CPU, c.Staging.CPU),
+			math.Round(c.Production.MemoryGB), math.Round(c.Staging.MemoryGB), scaleDown(c.Production.MemoryGB, c.Staging.MemoryGB),
+			math.Round(c.Production.StorageGB), math.Round(c.Staging.StorageGB), scaleDown(c.Production.StorageGB, c.Staging.StorageGB))
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** Staging mirrors %v%% of the repositories, including the largest repository and at most one monorepo, and is used by %v%% of the users, without high availability. It keeps up to %v replicas of the services production replicates, so that sharding and rolling updates behave like in production. Export it with the staging environment selected.</small>\n", e.stagingRepositoryPercent(), e.stagingUserPercent(), stagingMinReplicas)
+	fmt.Fprintf(buf, "\n")
+}
+
+// scaleDown formats the share of a production resource staging keeps.
+func scaleDown(production, staging float64) string {
+	if production == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", staging/production*100)
+}
+
+func (e *Estimate) markdownProjection(buf *bytes.Buffer, p *Projection) {
+	fmt.Fprintf(buf, "#### Growth projection\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Month | Users | Repositories | Repository size | vCPUs | Memory | Volume size |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, point := range p.Points {
+		pe := point.Estimate
+		fmt.Fprintf(buf, "| %v | %v | %v | %vg | %v | %vg | %vg |\n", point.Month, pe.Users, pe.Repositories, pe.TotalRepoSize, pe.TotalCPU, pe.TotalMemoryGB, pe.TotalStorageSize)
+	}
+	fmt.Fprintf(buf, "\n")
+	if len(p.Crossings) > 0 {
+		fmt.Fprintf(buf, "**Reference points crossed:**\n")
+		fmt.Fprintf(buf, "\n")
+		for _, c := range p.Crossings {
+			unit := ""
+			if c.Factor == ByTotalRepoSize || c.Factor == ByLargestRepoSize || c.Factor == ByLargestIndexSize {
+				unit = "g"
+			}
+			fmt.Fprintf(buf, "* Month %v: **%v** reaches %v%v %v\n", c.Month, c.Service, strconv.FormatFloat(c.Value, 'f', -1, 64), unit, c.Factor)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+	if len(p.PVCs) > 0 {
+		fmt.Fprintf(buf, "| Volume | Size now | Initial size for the horizon |\n")
+		fmt.Fprintf(buf, "|-------|:-------:|:-------:|\n")
+		for _, pvc := range p.PVCs {
+			fmt.Fprintf(buf, "| **%v** | %vg | %vg |\n", pvc.Service, pvc.Now, pvc.Recommended)
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+	fmt.Fprintf(buf, "<small>**Note:** Volumes are hard to resize, so provision the initial size for the horizon up front. It keeps the current number of replicas, each holding its share of the data at the end of the horizon. A service reaching a reference point is sized by the next one up from then on. Monorepos are as common among the new repositories as among the current ones, and repositories of the size distribution growing past %vg become monorepos.</small>\n", monorepoSizeGB)
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownAutoscaling(buf *bytes.Buffer, hpas []HorizontalPodAutoscaler) {
+	fmt.Fprintf(buf, "#### Horizontal Pod Autoscalers\n")
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Service | Min replicas | Max replicas | Target CPU utilization |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|\n")
+	for _, hpa := range hpas {
+		fmt.Fprintf(buf, "| **%v** | %v | %v | %v%% |\n", hpa.Name, hpa.MinReplicas, hpa.MaxReplicas, hpa.TargetCPUUtilization)
+	}
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "<small>**Note:** The minimum is the estimated replica count, and the maximum is the replica count of the next reference point up. Stateless services which would not scale beyond their estimate are not listed.</small>\n")
+	fmt.Fprintf(buf, "\n")
+}
+
+func (e *Estimate) markdownHighAvailability(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "#### High availability\n")
+	fmt.Fprintf(buf, "\n")
+	if e.DeploymentType != "kubernetes" {
+		fmt.Fprintf(buf, "High availability is only available for Kubernetes deployments.\n")
+		fmt.Fprintf(buf, "\n")
+		return
+	}
+	fmt.Fprintf(buf, "Stateless services run at least %v replicas. The estimated totals include %v vCPUs and %vg memory for the additional replicas and for rescheduling the largest pod while a node is drained.\n", highAvailabilityReplicas, e.HighAvailabilityCPU, e.HighAvailabilityMemoryGB)
+	fmt.Fprintf(buf, "\n")
+	fmt.Fprintf(buf, "| Service | Replica | PodDisruptionBudget | Topology spread |\n")
+	fmt.Fprintf(buf, "|-------|:-------:|:-------:|:-------:|\n")
+	for _, pdb := range e.PodDisruptionBudgets() {
+		fmt.Fprintf(buf, "| **%v** | %v | maxUnavailable: %v | across zones and nodes |\n", pdb.Name, pdb.Replicas, pdb.MaxUnavailable)
+	}
+	fmt.Fprintf(buf, "\n")
+	if limitations := e.singleReplicaServices(); len(limitations) > 0 {
+		fmt.Fprintf(buf, "**HA limitations:** The following stateful or singleton services run a single replica and are unavailable while their pod is rescheduled: %v.\n", strings.Join(limitations, ", "))
+		fmt.Fprintf(buf, "\n")
+	}
+}
+
+// MarkdownExport returns the cluster report: the resources of every instance, the node pool they
+// are packed onto and the quota of their namespaces.
+func (r *ClusterReport) MarkdownExport() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "### Cluster summary\n")
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "* **Instances:** %v\n", len(r.Instances))
+	fmt.Fprintf(&buf, "* **Node pool:** %v nodes with %v vCPUs and %vg memory\n", r.NodePool.Nodes, r.NodePool.Shape.CPU, r.NodePool.Shape.MEM)
+	fmt.Fprintf(&buf, "* **vCPUs:** %v requested, %v limit\n", math.Ceil(r.CPU.Request), math.Ceil(r.CPU.Limit))
+	fmt.Fprintf(&buf, "* **Memory:** %vg requested, %vg limit\n", math.Ceil(r.MemoryGB.Request), math.Ceil(r.MemoryGB.Limit))
+	fmt.Fprintf(&buf, "* **Volumes:** %vg\n", math.Ceil(r.StorageGB))
+	for _, u := range r.Instances {
+		if u.Estimate.ContactSupport {
+			fmt.Fprintf(&buf, "* **%v:** Estimation is currently not available for this instance size. Please [contact support](mailto:support@sourcegraph.com) for further assists.\n", u.Namespace)
+		}
+	}
+	if len(r.Unschedulable) > 0 {
+		fmt.Fprintf(&buf, "* **Unschedulable pods:** %v do not fit on the largest node shape\n", strings.Join(r.Unschedulable, ", "))
+	}
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "| Namespace | Engaged users | Repositories | Pods | vCPUs | Memory | Volumes |\n")
+	fmt.Fprintf(&buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, u := range r.Instances {
+		fmt.Fprintf(&buf, "| **%v** | %v | %v | %v | %v/%v | %vg/%vg | %vg |\n", u.Namespace, u.Estimate.EngagedUsers, u.Estimate.AverageRepositories, u.Pods, math.Ceil(u.CPU.Request), math.Ceil(u.CPU.Limit), math.Ceil(u.MemoryGB.Request), math.Ceil(u.MemoryGB.Limit), math.Ceil(u.StorageGB))
+	}
+	fmt.Fprintf(&buf, "| **Total** | | | %v | %v/%v | %vg/%vg | %vg |\n", r.Pods, math.Ceil(r.CPU.Request), math.Ceil(r.CPU.Limit), math.Ceil(r.MemoryGB.Request), math.Ceil(r.MemoryGB.Limit), math.Ceil(r.StorageGB))
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "#### Node pool\n")
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "| Node | Pods | vCPU requests | Memory requests |\n")
+	fmt.Fprintf(&buf, "|-------|:-------:|:-------:|:-------:|\n")
+	for i, n := range r.Nodes {
+		fmt.Fprintf(&buf, "| %v | %v | %.2f of %v | %.2fg of %vg |\n", i+1, len(n.Pods), n.CPU, r.NodePool.Shape.CPU, n.MemoryGB, r.NodePool.Shape.MEM)
+	}
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "<small>**Note:** Every replica of every instance is a pod, including the services at their default values. Pods are placed by their requests, the largest first, on the first node with room, keeping %v%% of each node for the kubelet and system daemons. The node shape is the one with the fewest vCPUs in total.</small>\n", nodeReservedRatio*100)
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "#### Namespace resource quotas\n")
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "| Namespace | requests.cpu | limits.cpu | requests.memory | limits.memory | limits.ephemeral-storage | requests.storage | pods | persistentvolumeclaims |\n")
+	fmt.Fprintf(&buf, "|-------|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|:-------:|\n")
+	for _, u := range r.Instances {
+		q := u.Quota
+		fmt.Fprintf(&buf, "| **%v** | %v | %v | %vG | %vG | %vG | %vGi | %v | %v |\n", u.Namespace, q.RequestsCPU, q.LimitsCPU, q.RequestsMemoryGB, q.LimitsMemoryGB, q.LimitsEphemeralGB, q.StorageGB, q.Pods, q.PersistentVolumeClaims)
+	}
+	fmt.Fprintf(&buf, "\n")
+	fmt.Fprintf(&buf, "<small>**Note:** Quotas leave room above the requests, limits and pods of the instance for the surge pods of rolling updates, %v%% unless the instance sets another headroom, and cover the size of its volumes. Export the estimate of each instance, and its ResourceQuota and LimitRange, to deploy it in its namespace.</small>\n", DefaultQuotaHeadroomPercent)
+	fmt.Fprintf(&buf, "\n")
+	return buf.Bytes()
+}
+
 func (e *Estimate) HelmExport() string {
-	var c = e.Services
-	j, err := json.Marshal(c)
+	var c = make(map[string]Service, len(e.Services))
+	for name, service := range e.Services {
+		c[name] = service
+	}
+	var disabled = make(map[string]map[string]bool)
+	for name := range defaults {
+		if !e.deployed(name) {
+			disabled[helmKey(name)] = map[string]bool{"enabled": false}
+		}
+	}
+	for _, hpa := range e.HorizontalPodAutoscalers() {
+		service := c[hpa.Service]
+		service.Autoscaling = &Autoscaling{
+			Enabled:                        true,
+			MinReplicas:                    hpa.MinReplicas,
+			MaxReplicas:                    hpa.MaxReplicas,
+			TargetCPUUtilizationPercentage: hpa.TargetCPUUtilization,
+		}
+		c[hpa.Service] = service
+	}
+	for name, storageClass := range e.Storage.StorageClasses() {
+		if service, ok := c[name]; ok {
+			service.StorageClassName = storageClass
+			c[name] = service
+		}
+	}
+	for _, pdb := range e.PodDisruptionBudgets() {
+		service := c[pdb.Service]
+		service.PodDisruptionBudget = &PodDisruptionBudgetValues{Enabled: true, MaxUnavailable: pdb.MaxUnavailable}
+		service.TopologySpreadConstraints = topologySpreadConstraints(pdb.Name)
+		c[pdb.Service] = service
+	}
+	values := make(map[string]Service, len(c))
+	for name, service := range c {
+		values[helmKey(name)] = service
+	}
+	s := strings.ReplaceAll(toYAML(values), `"`, "'")
+	if len(disabled) > 0 {
+		s += strings.ReplaceAll(toYAML(disabled), `"`, "'")
+	}
+	return s
+}
+
+// plural returns the count followed by the singular or plural form of a word.
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%v %v", n, singular)
+	}
+	return fmt.Sprintf("%v %v", n, plural)
+}
+
+// toYAML marshals v to JSON and converts it to YAML.
+func toYAML(v interface{}) string {
+	j, err := json.Marshal(v)
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 	}
@@ -160,22 +670,13 @@ func (e *Estimate) HelmExport() string {
 	if err != nil {
 		fmt.Printf("err: %v\n", err)
 	}
-	s := strings.ReplaceAll(string(y), `"`, "'")
-	return s
+	return string(y)
 }
 
 func (e *Estimate) DockerExport() string {
 	var d DockerServices
 	d.Version = "2.4"
 	d.Services = e.DockerServices
-	j, err := json.Marshal(d)
-	if err != nil {
-		fmt.Printf("err: %v\n", err)
-	}
-	y, err := yaml.JSONToYAML(j)
-	if err != nil {
-		fmt.Printf("err: %v\n", err)
-	}
-	s := strings.ReplaceAll(string(y), `"`, "")
+	s := strings.ReplaceAll(toYAML(d), `"`, "")
 	return s
 }
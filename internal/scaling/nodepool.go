@@ -0,0 +1,44 @@
+package scaling
+
+import "math"
+
+// NodeShape describes the vCPU and memory (GB) of a single Kubernetes node.
+type NodeShape struct {
+	CPU, MEM float64
+}
+
+// nodeShapes are general purpose (1 vCPU : 4 GB) node shapes offered by all major cloud
+// providers, in ascending order.
+var nodeShapes = []NodeShape{
+	{CPU: 8, MEM: 32},
+	{CPU: 16, MEM: 64},
+	{CPU: 32, MEM: 128},
+	{CPU: 48, MEM: 192},
+	{CPU: 64, MEM: 256},
+	{CPU: 96, MEM: 384},
+}
+
+// NodePool is a recommended Kubernetes node pool.
+type NodePool struct {
+	Nodes int
+	Shape NodeShape
+}
+
+// smallestNodeShape returns the smallest node shape that can run a pod with the given CPU and
+// memory limits. If no shape is large enough, the largest shape is returned.
+func smallestNodeShape(cpu, mem float64) NodeShape {
+	for _, s := range nodeShapes {
+		if s.CPU >= cpu && s.MEM >= mem {
+			return s
+		}
+	}
+	return nodeShapes[len(nodeShapes)-1]
+}
+
+// NodePool recommends a node pool for the estimate: the smallest node shape that fits the largest
+// service, and enough nodes of that shape to provide the estimated total vCPUs and memory.
+func (e *Estimate) NodePool() NodePool {
+	shape := smallestNodeShape(float64(e.TotalSharedCPU), float64(e.TotalSharedMemoryGB))
+	nodes := math.Ceil(math.Max(float64(e.TotalCPU)/shape.CPU, float64(e.TotalMemoryGB)/shape.MEM))
+	return NodePool{Nodes: int(math.Max(nodes, 1)), Shape: shape}
+}
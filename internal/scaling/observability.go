@@ -0,0 +1,245 @@
+package scaling
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	DefaultPrometheusRetentionDays = 15
+	DefaultScrapeIntervalSeconds   = 30
+
+	// Every replica exposes about 5000 series: its own metrics, including histograms, and the
+	// container metrics cadvisor reports for it.
+	seriesPerReplica = 5000
+	// Prometheus, cadvisor and the Kubernetes or Docker metrics of the host.
+	baseSeries = 20000
+
+	// Prometheus stores about 2 bytes per sample once compacted, and needs room for the WAL and
+	// compactions.
+	prometheusBytesPerSample  = 2
+	prometheusStorageHeadroom = 1.5
+	// Prometheus keeps about 10KB per active series in memory, on top of 2GB for queries.
+	prometheusKBPerSeries  = 10
+	prometheusBaseMemoryGB = 2
+	// A vCPU ingests about 50000 samples per second.
+	prometheusSamplesPerCPUSecond = 50000
+
+	// Grafana loads the series of a dashboard to render it, about 1GB per million series.
+	grafanaGBPerMillionSeries = 1
+	// otel-collector receives the traces and metrics of every replica.
+	otelCollectorMBPerReplica = 50
+
+	// At peak, a user makes about one request every 2 seconds, and a traced request has about 50
+	// spans.
+	requestsPerPeakUserPerSecond = 0.5
+	spansPerRequest              = 50
+	// jaeger keeps the spans of the last 30 minutes in memory, about 1KB each, and a vCPU of
+	// jaeger or otel-collector handles about 5000 spans per second.
+	jaegerRetentionSeconds = 30 * 60
+	spanBytes              = 1000
+	spansPerCPUSecond      = 5000
+)
+
+// The observability modes: the bundled monitoring stack, sending telemetry to an external stack,
+// or no monitoring at all.
+const (
+	ObservabilityBundled  = "bundled"
+	ObservabilityExternal = "external"
+	ObservabilityDisabled = "disabled"
+)
+
+var (
+	PrometheusRetentionDaysRange = Range{0, 365}
+	ScrapeIntervalSecondsRange   = Range{0, 300}
+	ActiveSeriesRange            = Range{0, 50000000}
+	TraceSamplingRateRange       = Range{0, 100}
+)
+
+// ObservabilityPlan is the metrics the monitoring stack collects, and the capacity required to
+// keep them.
+type ObservabilityPlan struct {
+	Replicas              int // replicas monitored, excluding the monitoring stack
+	Series                int
+	SamplesPerSecond      float64
+	RetentionDays         int
+	ScrapeIntervalSeconds int
+	PrometheusStorageGB   float64
+	PrometheusMemoryGB    float64
+	// SpansPerSecond is the number of spans traced at peak.
+	SpansPerSecond float64
+}
+
+// observabilityServices are the services of the monitoring stack sized from the metrics and
+// traces.
+var observabilityServices = []struct {
+	Service, Label, DockerServiceName, PodName string
+}{
+	{"prometheus", "prometheus", "prometheus", "prometheus"},
+	{"grafana", "grafana", "grafana", "grafana"},
+	{"jaeger", "jaeger", "jaeger", "jaeger"},
+	{"otel-collector", "otel-collector", "otel-collector", "otel-collector"},
+}
+
+// externalObservability are the services which are not deployed when telemetry is sent to an
+// external stack: cadvisor still exports container metrics and otel-collector forwards traces.
+var externalObservability = map[string]bool{"prometheus": true, "grafana": true, "jaeger": true}
+
+// helmKeys are the keys of the services in the values of the Sourcegraph Helm chart, where they
+// differ from the name of the service.
+var helmKeys = map[string]string{"otel-collector": "openTelemetryCollector"}
+
+func helmKey(service string) string {
+	if key, ok := helmKeys[service]; ok {
+		return key
+	}
+	return service
+}
+
+// undeployed returns the services of the monitoring stack which are not deployed in the
+// observability mode.
+func (e *Estimate) undeployed() []string {
+	var names []string
+	for _, name := range []string{"prometheus", "grafana", "jaeger", "otel-collector", "cadvisor"} {
+		if !e.deployed(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (e *Estimate) observabilityMode() string {
+	switch e.ObservabilityMode {
+	case ObservabilityExternal, ObservabilityDisabled:
+		return e.ObservabilityMode
+	default:
+		return ObservabilityBundled
+	}
+}
+
+// deployed reports whether the service is deployed in the observability mode.
+func (e *Estimate) deployed(service string) bool {
+	switch e.observabilityMode() {
+	case ObservabilityExternal:
+		return !externalObservability[service]
+	case ObservabilityDisabled:
+		return !externalObservability[service] && service != "cadvisor" && service != "otel-collector"
+	default:
+		return true
+	}
+}
+
+func (e *Estimate) prometheusRetentionDays() int {
+	if e.PrometheusRetentionDays <= 0 {
+		return DefaultPrometheusRetentionDays
+	}
+	return e.PrometheusRetentionDays
+}
+
+func (e *Estimate) scrapeIntervalSeconds() int {
+	if e.ScrapeIntervalSeconds <= 0 {
+		return DefaultScrapeIntervalSeconds
+	}
+	return e.ScrapeIntervalSeconds
+}
+
+// planObservability sizes the monitoring stack for the metrics and traces of the other services,
+// and removes the services the observability mode does not deploy. It runs once the replicas of
+// the other services are known.
+func (e *Estimate) planObservability() {
+	p := ObservabilityPlan{
+		RetentionDays:         e.prometheusRetentionDays(),
+		ScrapeIntervalSeconds: e.scrapeIntervalSeconds(),
+	}
+	monitoring := map[string]bool{"cadvisor": true}
+	for _, s := range observabilityServices {
+		monitoring[s.Service] = true
+	}
+	for name, s := range e.Services {
+		if !monitoring[name] {
+			p.Replicas += int(math.Max(float64(s.Replicas), 1))
+		}
+	}
+	for name, d := range defaults {
+		if _, ok := e.Services[name]; !ok && !monitoring[name] {
+			p.Replicas += int(math.Max(float64(d[e.DeploymentType].Replicas), 1))
+		}
+	}
+	p.Series = e.ActiveSeries
+	if p.Series <= 0 {
+		p.Series = baseSeries + p.Replicas*seriesPerReplica
+	}
+	p.SamplesPerSecond = float64(p.Series) / float64(p.ScrapeIntervalSeconds)
+	retentionSeconds := float64(p.RetentionDays) * 24 * 60 * 60
+	p.PrometheusStorageGB = math.Ceil(p.SamplesPerSecond * retentionSeconds * prometheusBytesPerSample * prometheusStorageHeadroom / 1e9)
+	p.PrometheusMemoryGB = prometheusBaseMemoryGB + float64(p.Series)*prometheusKBPerSeries/1e6
+	p.SpansPerSecond = float64(e.PeakUsers) * requestsPerPeakUserPerSecond * spansPerRequest * float64(e.TraceSamplingRate) / 100
+	e.Observability = p
+
+	for _, s := range observabilityServices {
+		if !e.deployed(s.Service) {
+			delete(e.Services, s.Service)
+			continue
+		}
+		v := defaults[s.Service][e.DeploymentType]
+		v.Label = s.Label
+		v.NameInDocker = s.DockerServiceName
+		v.PodName = s.PodName
+		o := v
+		raised := false
+		switch s.Service {
+		case "prometheus":
+			cpu := p.SamplesPerSecond / prometheusSamplesPerCPUSecond
+			o.Resources = Resources{
+				Requests: Resource{CPU: cpu / 2, MEM: p.PrometheusMemoryGB},
+				Limits:   Resource{CPU: cpu, MEM: p.PrometheusMemoryGB},
+			}
+			if p.PrometheusStorageGB > v.Storage {
+				v.Storage = p.PrometheusStorageGB
+				raised = true
+			}
+		case "grafana":
+			memoryGB := float64(p.Series) / 1e6 * grafanaGBPerMillionSeries
+			o.Resources = Resources{Requests: Resource{MEM: memoryGB}, Limits: Resource{MEM: memoryGB}}
+		case "jaeger":
+			cpu := p.SpansPerSecond / spansPerCPUSecond
+			memoryGB := p.SpansPerSecond * jaegerRetentionSeconds * spanBytes / 1e9
+			o.Resources = Resources{Requests: Resource{CPU: cpu / 2, MEM: memoryGB}, Limits: Resource{CPU: cpu, MEM: memoryGB}}
+		case "otel-collector":
+			cpu := p.SpansPerSecond / spansPerCPUSecond
+			memoryGB := float64(p.Replicas) * otelCollectorMBPerReplica / 1000
+			o.Resources = Resources{Requests: Resource{CPU: cpu / 2, MEM: memoryGB / 3}, Limits: Resource{CPU: cpu, MEM: memoryGB}}
+		}
+		if e.DeploymentType == "docker-compose" {
+			// Docker Compose only sets limits.
+			o.Resources.Requests = v.Resources.Requests
+		}
+		// The defaults are kept as they are, and only need their units for the exports.
+		v.Resources.Requests.CPUS = strings.ToLower(addUnit(v.Resources.Requests.CPU, ""))
+		v.Resources.Limits.CPUS = strings.ToLower(addUnit(v.Resources.Limits.CPU, ""))
+		v.Resources.Requests.MEMS = addUnit(v.Resources.Requests.MEM, "G")
+		v.Resources.Limits.MEMS = addUnit(v.Resources.Limits.MEM, "G")
+		if v.Storage > 0 {
+			v.setStorage(v.Storage)
+		}
+		// Traces drive the CPU of jaeger and otel-collector, and the metrics everything else.
+		cpuFactor, memoryFactor := ByActiveSeries, ByActiveSeries
+		switch s.Service {
+		case "jaeger":
+			cpuFactor, memoryFactor = ByTraceSampling, ByTraceSampling
+		case "otel-collector":
+			cpuFactor = ByTraceSampling
+		}
+		if o.Resources.Requests.CPU > v.Resources.Requests.CPU || o.Resources.Limits.CPU > v.Resources.Limits.CPU {
+			v.sizedBy(cpuFactor)
+		}
+		if o.Resources.Requests.MEM > v.Resources.Requests.MEM || o.Resources.Limits.MEM > v.Resources.Limits.MEM {
+			v.sizedBy(memoryFactor)
+		}
+		if raised {
+			v.sizedBy(ByActiveSeries)
+		}
+		v.raise(&o)
+		e.Services[s.Service] = v
+	}
+}
@@ -0,0 +1,187 @@
+package scaling
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultQuotaHeadroomPercent is the room a namespace quota leaves above the estimate, for the
+// surge pods of rolling updates.
+const DefaultQuotaHeadroomPercent = 20
+
+var QuotaHeadroomRange = Range{0, 200}
+
+// ResourceQuota is the quota of the namespace of an instance: every replica of its services,
+// plus the headroom.
+type ResourceQuota struct {
+	RequestsCPU, LimitsCPU                 float64
+	RequestsMemoryGB, LimitsMemoryGB       float64
+	RequestsEphemeralGB, LimitsEphemeralGB float64
+	StorageGB                              float64
+	Pods, PersistentVolumeClaims           int
+}
+
+// LimitRange is the range of resources of a container in the namespace of an instance. The
+// defaults and maximums are those of the largest services of the estimate.
+type LimitRange struct {
+	Max, Default, DefaultRequest Resource
+}
+
+func (e *Estimate) quotaHeadroomPercent() int {
+	if e.QuotaHeadroomPercent <= 0 {
+		return DefaultQuotaHeadroomPercent
+	}
+	return e.QuotaHeadroomPercent
+}
+
+// ResourceQuota sums the requests, limits, ephemeral storage and volumes of every replica of the
+// services of the estimate, and of the deployed services without an estimate at their defaults.
+func (e *Estimate) ResourceQuota() ResourceQuota {
+	headroom := 1 + float64(e.quotaHeadroomPercent())/100
+	var q ResourceQuota
+	var pods int
+	for _, pod := range e.clusterPods("") {
+		pods++
+		q.RequestsCPU += pod.Requests.CPU
+		q.LimitsCPU += pod.Limits.CPU
+		q.RequestsMemoryGB += pod.Requests.MEM
+		q.LimitsMemoryGB += pod.Limits.MEM
+		q.RequestsEphemeralGB += pod.Requests.EPH
+		q.LimitsEphemeralGB += pod.Limits.EPH
+		q.StorageGB += pod.Storage
+		if pod.Storage > 0 {
+			q.PersistentVolumeClaims++
+		}
+	}
+	q.RequestsCPU = math.Ceil(q.RequestsCPU * headroom)
+	q.LimitsCPU = math.Ceil(q.LimitsCPU * headroom)
+	q.RequestsMemoryGB = math.Ceil(q.RequestsMemoryGB * headroom)
+	q.LimitsMemoryGB = math.Ceil(q.LimitsMemoryGB * headroom)
+	q.RequestsEphemeralGB = math.Ceil(q.RequestsEphemeralGB * headroom)
+	q.LimitsEphemeralGB = math.Ceil(q.LimitsEphemeralGB * headroom)
+	// Volumes are not replaced by rolling updates.
+	q.StorageGB = math.Ceil(q.StorageGB)
+	q.Pods = int(math.Ceil(float64(pods) * headroom))
+	return q
+}
+
+// LimitRange returns the largest requests and limits of a container of the estimate, as the
+// defaults and maximums of the containers of its namespace.
+func (e *Estimate) LimitRange() LimitRange {
+	var l LimitRange
+	largest := func(s Service) {
+		replicas := math.Max(float64(s.Replicas), 1)
+		l.Max.CPU = math.Max(l.Max.CPU, s.Resources.Limits.CPU)
+		l.Max.MEM = math.Max(l.Max.MEM, s.Resources.Limits.MEM)
+		l.Max.EPH = math.Max(l.Max.EPH, s.Resources.Limits.EPH/replicas)
+		l.DefaultRequest.CPU = math.Max(l.DefaultRequest.CPU, s.Resources.Requests.CPU)
+		l.DefaultRequest.MEM = math.Max(l.DefaultRequest.MEM, s.Resources.Requests.MEM)
+		l.DefaultRequest.EPH = math.Max(l.DefaultRequest.EPH, s.Resources.Requests.EPH/replicas)
+	}
+	for _, s := range e.Services {
+		largest(s)
+	}
+	for name, d := range defaults {
+		if _, ok := e.Services[name]; ok || !e.deployed(name) {
+			continue
+		}
+		if s, ok := d[e.DeploymentType]; ok {
+			largest(s)
+		}
+	}
+	// Round up so that the largest services stay within the range.
+	for _, r := range []*Resource{&l.Max, &l.DefaultRequest} {
+		r.CPU, r.MEM, r.EPH = roundUp(r.CPU), roundUp(r.MEM), roundUp(r.EPH)
+	}
+	l.Default = l.Max
+	return l
+}
+
+// roundUp rounds numbers > 1 up to a whole number, and numbers < 1 up to a thousandth, the
+// precision of the quantities.
+func roundUp(f float64) float64 {
+	if f > 1 {
+		return math.Ceil(f)
+	}
+	return math.Ceil(f*1000) / 1000
+}
+
+type quotaManifest struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   objectMetadata `json:"metadata"`
+	Spec       quotaSpec      `json:"spec"`
+}
+
+type quotaSpec struct {
+	Hard map[string]string `json:"hard"`
+}
+
+type limitRangeManifest struct {
+	APIVersion string         `json:"apiVersion"`
+	Kind       string         `json:"kind"`
+	Metadata   objectMetadata `json:"metadata"`
+	Spec       limitRangeSpec `json:"spec"`
+}
+
+type limitRangeSpec struct {
+	Limits []limitRangeItem `json:"limits"`
+}
+
+type limitRangeItem struct {
+	Type           string            `json:"type"`
+	Max            map[string]string `json:"max"`
+	Default        map[string]string `json:"default"`
+	DefaultRequest map[string]string `json:"defaultRequest"`
+}
+
+// quantities returns the CPU, memory and ephemeral storage of a resource as Kubernetes quantities.
+func quantities(r Resource) map[string]string {
+	q := map[string]string{
+		"cpu":    strings.ToLower(addUnit(r.CPU, "")),
+		"memory": addUnit(r.MEM, "G"),
+	}
+	if r.EPH > 0 {
+		q["ephemeral-storage"] = addUnit(r.EPH, "G")
+	}
+	return q
+}
+
+// QuotaExport returns the ResourceQuota and LimitRange manifests of the namespace of the
+// estimate.
+func (e *Estimate) QuotaExport() string {
+	q := e.ResourceQuota()
+	hard := map[string]string{
+		"requests.cpu":               fmt.Sprint(q.RequestsCPU),
+		"limits.cpu":                 fmt.Sprint(q.LimitsCPU),
+		"requests.memory":            fmt.Sprint(q.RequestsMemoryGB, "G"),
+		"limits.memory":              fmt.Sprint(q.LimitsMemoryGB, "G"),
+		"requests.ephemeral-storage": fmt.Sprint(q.RequestsEphemeralGB, "G"),
+		"limits.ephemeral-storage":   fmt.Sprint(q.LimitsEphemeralGB, "G"),
+		"requests.storage":           fmt.Sprint(q.StorageGB, "Gi"),
+		"persistentvolumeclaims":     fmt.Sprint(q.PersistentVolumeClaims),
+		"pods":                       fmt.Sprint(q.Pods),
+	}
+	l := e.LimitRange()
+	docs := []string{
+		toYAML(quotaManifest{
+			APIVersion: "v1",
+			Kind:       "ResourceQuota",
+			Metadata:   e.metadata("sourcegraph"),
+			Spec:       quotaSpec{Hard: hard},
+		}),
+		toYAML(limitRangeManifest{
+			APIVersion: "v1",
+			Kind:       "LimitRange",
+			Metadata:   e.metadata("sourcegraph"),
+			Spec: limitRangeSpec{Limits: []limitRangeItem{{
+				Type:           "Container",
+				Max:            quantities(l.Max),
+				Default:        quantities(l.Default),
+				DefaultRequest: quantities(l.DefaultRequest),
+			}}},
+		}),
+	}
+	return strings.Join(docs, "---\n")
+}
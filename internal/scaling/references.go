@@ -283,7 +283,8 @@ var References = []ServiceScale{
 			{Resources: Resources{Requests: Resource{MEM: 4}, Limits: Resource{MEM: 8}}, Value: AverageRepositoriesRange.Min},  // default
 		},
 	},
-	// CPU usage and replicas scale based on the number of average repos it must index as it indexes one repo at a time
+	// CPU usage scales based on the number of average repos it must index as it indexes one repo at a time,
+	// and is raised to keep up with the expected reindex throughput by planIndexedSearch.
 	// Set replica number to 0 as it will be synced with the replica number for webserver
 	{
 		ServiceName:       "indexedSearch",
@@ -301,23 +302,9 @@ var References = []ServiceScale{
 		},
 	},
 
-	// zoekt-webserver memory usage and replicas scale based on how many average repositories it is
-	// serving (roughly 2/3 the size of the actual repos is the memory usage).
-	{
-		ServiceName:       "indexedSearchIndexer",
-		DockerServiceName: "zoekt-webserver-0",
-		ServiceLabel:      "zoekt-webserver",
-		PodName:           "indexed-search",
-		ScalingFactor:     ByAverageRepositories,
-		ReferencePoints: []Service{
-			{Replicas: 50, Resources: Resources{Requests: Resource{MEM: 2160}, Limits: Resource{MEM: 4320}}, Value: AverageRepositoriesRange.Max}, // Cloud
-			{Replicas: 5, Resources: Resources{Requests: Resource{MEM: 24}, Limits: Resource{MEM: 48}}, Value: 500000},                            // Size XL
-			{Replicas: 3, Resources: Resources{Requests: Resource{MEM: 16}, Limits: Resource{MEM: 32}}, Value: 250000},                            // Size L
-			{Replicas: 2, Resources: Resources{Requests: Resource{MEM: 8}, Limits: Resource{MEM: 16}}, Value: 100000},                             // Size M
-			{Replicas: 1, Resources: Resources{Requests: Resource{MEM: 4}, Limits: Resource{MEM: 8}}, Value: 5000},                                // Size S
-			{Replicas: 1, Resources: Resources{Requests: Resource{MEM: 2}, Limits: Resource{MEM: 4}}, Value: AverageRepositoriesRange.Min},        // default / Size XS
-		},
-	},
+	// zoekt-webserver memory usage and replicas scale based on the size of the index it is serving
+	// rather than the number of repositories, see planIndexedSearch.
+	//
 	// CPU usage is based on the number of users it serves (and the size of the index, but we do not account for
 	// that here and instead assume a correlation between # users and # repos which is generally true.)
 	{
@@ -340,10 +327,13 @@ var References = []ServiceScale{
 		DockerServiceName: "codeinsights-db",
 		ServiceLabel:      "codeinsights-db",
 		PodName:           "codeinsights-db",
-		ScalingFactor:     ByLargestIndexSize,
+		ScalingFactor:     ByInsightSeriesRepos,
 		ReferencePoints: []Service{
-			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 2, MEM: 2}, Limits: Resource{CPU: 4, MEM: 4}}, Storage: 200, Value: LargestIndexSizeRange.Max}, // Enabled
-			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 2, MEM: 2}, Limits: Resource{CPU: 4, MEM: 4}}, Storage: 200, Value: LargestIndexSizeRange.Min}, // Disabled
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 16, MEM: 32}, Limits: Resource{CPU: 16, MEM: 64}}, Storage: 200, Value: InsightSeriesReposRange.Max}, // estimate
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 8, MEM: 16}, Limits: Resource{CPU: 8, MEM: 32}}, Storage: 200, Value: 100000000},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 4, MEM: 8}, Limits: Resource{CPU: 8, MEM: 16}}, Storage: 200, Value: 10000000},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 2, MEM: 2}, Limits: Resource{CPU: 4, MEM: 4}}, Storage: 200, Value: 1000000},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 2, MEM: 2}, Limits: Resource{CPU: 4, MEM: 4}}, Storage: 200, Value: InsightSeriesReposRange.Min}, // default
 		},
 	},
 	{
@@ -357,16 +347,86 @@ var References = []ServiceScale{
 			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 4, MEM: 4}, Limits: Resource{CPU: 4, MEM: 4}}, Storage: 200, Value: LargestIndexSizeRange.Min}, // Disabled
 		},
 	},
-	// Use default values
+}
+
+// LoadReferences are reference points for the search load. Unlike References, which size services
+// from the number of users and repositories, they only raise services which would otherwise be
+// too small for the expected search traffic.
+var LoadReferences = []ServiceScale{
+	// Every search goes through sourcegraph-frontend, which needs more replicas to handle many
+	// concurrent requests.
 	{
-		ServiceName:       "prometheus",
-		DockerServiceName: "prometheus",
-		ServiceLabel:      "prometheus",
-		PodName:           "prometheus",
-		ScalingFactor:     ByLargestIndexSize,
+		ServiceName:       "frontend",
+		ServiceLabel:      "sourcegraph-frontend",
+		DockerServiceName: "sourcegraph-frontend-0",
+		PodName:           "frontend",
+		ScalingFactor:     BySearchQPS,
+		ReferencePoints: []Service{
+			{Replicas: 12, Value: SearchQPSRange.Max * automationQueryCost}, // automation heavy at the maximum QPS
+			{Replicas: 6, Value: 200},
+			{Replicas: 3, Value: 50},
+			{Replicas: 1, Value: SearchQPSRange.Min},
+		},
+	},
+	// Unindexed and structural searches, which automation often runs, are served by searcher.
+	{
+		ServiceName:       "searcher",
+		ServiceLabel:      "searcher",
+		DockerServiceName: "searcher-0",
+		PodName:           "searcher",
+		ScalingFactor:     BySearchQPS,
+		ReferencePoints: []Service{
+			{Replicas: 20, Resources: Resources{Requests: Resource{CPU: 8}, Limits: Resource{CPU: 16}}, Value: SearchQPSRange.Max * automationQueryCost}, // automation heavy at the maximum QPS
+			{Replicas: 8, Resources: Resources{Requests: Resource{CPU: 6}, Limits: Resource{CPU: 12}}, Value: 200},
+			{Replicas: 4, Resources: Resources{Requests: Resource{CPU: 4}, Limits: Resource{CPU: 8}}, Value: 50},
+			{Replicas: 2, Resources: Resources{Requests: Resource{CPU: 2}, Limits: Resource{CPU: 4}}, Value: 10},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: .5}, Limits: Resource{CPU: 2}}, Value: SearchQPSRange.Min},
+		},
+	},
+	// Indexed searches are sent to every zoekt-webserver replica, so more replicas only help by
+	// making each one search fewer shards; most of the load is CPU.
+	{
+		ServiceName:       "indexedSearchIndexer",
+		DockerServiceName: "zoekt-webserver-0",
+		ServiceLabel:      "zoekt-webserver",
+		PodName:           "indexed-search",
+		ScalingFactor:     BySearchQPS,
+		ReferencePoints: []Service{
+			{Replicas: 4, Resources: Resources{Requests: Resource{CPU: 32}, Limits: Resource{CPU: 64}}, Value: SearchQPSRange.Max * automationQueryCost}, // automation heavy at the maximum QPS
+			{Replicas: 2, Resources: Resources{Requests: Resource{CPU: 16}, Limits: Resource{CPU: 32}}, Value: 200},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 8}, Limits: Resource{CPU: 16}}, Value: 50},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 4}, Limits: Resource{CPU: 8}}, Value: 10},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: .5}, Limits: Resource{CPU: 2}}, Value: SearchQPSRange.Min},
+		},
+	},
+	// Code insights backfill every series over its repositories: worker schedules and records the
+	// data points, and gitserver searches the history of every repository.
+	{
+		ServiceName:       "worker",
+		ServiceLabel:      "worker",
+		DockerServiceName: "worker",
+		PodName:           "worker",
+		ScalingFactor:     ByInsightSeriesRepos,
+		ReferencePoints: []Service{
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 8, MEM: 16}, Limits: Resource{CPU: 16, MEM: 32}}, Value: InsightSeriesReposRange.Max},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 4, MEM: 8}, Limits: Resource{CPU: 8, MEM: 16}}, Value: 100000000},
+			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: 2, MEM: 4}, Limits: Resource{CPU: 4, MEM: 8}}, Value: 10000000},
+			{Value: 1000000},
+			{Value: InsightSeriesReposRange.Min},
+		},
+	},
+	{
+		ServiceName:       "gitserver",
+		ServiceLabel:      "gitserver",
+		DockerServiceName: "gitserver-0",
+		PodName:           "gitserver",
+		ScalingFactor:     ByInsightSeriesRepos,
 		ReferencePoints: []Service{
-			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: .5, MEM: 6}, Limits: Resource{CPU: 2, MEM: 6}}, Storage: 200, Value: LargestIndexSizeRange.Max}, // Enabled
-			{Replicas: 1, Resources: Resources{Requests: Resource{CPU: .5, MEM: 6}, Limits: Resource{CPU: 2, MEM: 6}}, Storage: 200, Value: LargestIndexSizeRange.Min}, // Disabled
+			{Resources: Resources{Requests: Resource{CPU: 16}, Limits: Resource{CPU: 32}}, Value: InsightSeriesReposRange.Max},
+			{Resources: Resources{Requests: Resource{CPU: 8}, Limits: Resource{CPU: 16}}, Value: 100000000},
+			{Resources: Resources{Requests: Resource{CPU: 4}, Limits: Resource{CPU: 8}}, Value: 10000000},
+			{Value: 1000000},
+			{Value: InsightSeriesReposRange.Min},
 		},
 	},
 }
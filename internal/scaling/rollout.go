@@ -0,0 +1,104 @@
+package scaling
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultCloneThroughputMBps is the network throughput from the code hosts to each gitserver
+	// replica, about 1 Gbps.
+	DefaultCloneThroughputMBps = 100
+	// DefaultDiskThroughputMBps is the write throughput of a gitserver volume.
+	DefaultDiskThroughputMBps = 200
+
+	// Every clone has a fixed cost (negotiation, pack indexing) of about 2 seconds, and gitserver
+	// clones up to 5 repositories at a time per replica.
+	cloneOverheadSeconds = 2
+	cloneConcurrency     = 5
+)
+
+var (
+	CloneThroughputRange = Range{0, 10000}
+	DiskThroughputRange  = Range{0, 10000}
+)
+
+// RolloutPlan is how long the initial rollout takes: until every repository is cloned, and until
+// every repository is indexed and searchable.
+type RolloutPlan struct {
+	Options []RolloutOption // the current replicas first, then more replicas
+}
+
+// RolloutOption is the duration of the rollout with a number of gitserver and indexed-search
+// replicas.
+type RolloutOption struct {
+	GitserverReplicas, IndexedSearchReplicas int
+	Cloned, Searchable                       time.Duration
+}
+
+// rolloutMultipliers are the replica counts compared with the current ones.
+var rolloutMultipliers = []int{1, 2, 4}
+
+func (e *Estimate) cloneThroughputMBps() float64 {
+	if e.CloneThroughputMBps <= 0 {
+		return DefaultCloneThroughputMBps
+	}
+	return float64(e.CloneThroughputMBps)
+}
+
+func (e *Estimate) diskThroughputMBps() float64 {
+	if e.DiskThroughputMBps <= 0 {
+		return DefaultDiskThroughputMBps
+	}
+	return float64(e.DiskThroughputMBps)
+}
+
+// rollout returns the duration of the rollout with the given replicas. Each gitserver replica
+// clones its share of the repositories, at the network or disk throughput, whichever is lower.
+// zoekt-indexserver indexes repositories as they are cloned, so the rollout is searchable once
+// both are done and the last repository, at worst the largest one, is indexed.
+func (e *Estimate) rollout(gitserverReplicas, indexedSearchReplicas int) RolloutOption {
+	o := RolloutOption{GitserverReplicas: gitserverReplicas, IndexedSearchReplicas: indexedSearchReplicas}
+	throughput := math.Min(e.cloneThroughputMBps(), e.diskThroughputMBps())
+	transferSeconds := float64(e.TotalRepoSize) * 1000 / throughput / float64(gitserverReplicas)
+	overheadSeconds := float64(e.Repositories) * cloneOverheadSeconds / cloneConcurrency / float64(gitserverReplicas)
+	cloned := math.Max(transferSeconds, overheadSeconds)
+	o.Cloned = seconds(cloned)
+
+	cpu := math.Max(e.Services["indexedSearch"].Resources.Limits.CPU, 1)
+	rate := zoektIndexMBPerCoreSecond * cpu
+	indexSeconds := float64(e.TotalRepoSize) * 1000 * zoektSourceRatio / rate / float64(indexedSearchReplicas)
+	largestSeconds := float64(e.LargestRepoSize) * 1000 * zoektSourceRatio / rate
+	o.Searchable = seconds(math.Max(cloned, indexSeconds) + largestSeconds)
+	return o
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+// planRollout estimates the rollout with the current replicas, and with more of them.
+func (e *Estimate) planRollout() {
+	gitserver := int(math.Max(float64(e.Services["gitserver"].Replicas), 1))
+	indexedSearch := int(math.Max(float64(e.Services["indexedSearch"].Replicas), 1))
+	e.Rollout = RolloutPlan{}
+	for _, m := range rolloutMultipliers {
+		e.Rollout.Options = append(e.Rollout.Options, e.rollout(gitserver*m, indexedSearch*m))
+	}
+}
+
+// formatDuration formats a duration in days, hours and minutes.
+func formatDuration(d time.Duration) string {
+	minutes := int(math.Ceil(d.Minutes()))
+	days, hours := minutes/(24*60), minutes/60%24
+	minutes %= 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%vd %vh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%vh %vm", hours, minutes)
+	default:
+		return fmt.Sprintf("%vm", minutes)
+	}
+}
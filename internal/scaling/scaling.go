@@ -17,8 +17,45 @@ const (
 	ByLargestRepoSize     Factor = iota
 	ByLargestIndexSize    Factor = iota
 	ByUserRepoSumRatio    Factor = iota
+	BySearchQPS           Factor = iota
+	ByInsightSeriesRepos  Factor = iota
+	BySCIPUploads         Factor = iota
+	ByActiveSeries        Factor = iota
+	ByTraceSampling       Factor = iota
 )
 
+// String returns the input the factor is based on, as shown in the "Sized by" column.
+func (f Factor) String() string {
+	switch f {
+	case ByEngagedUsers:
+		return "engaged users"
+	case ByAverageRepositories:
+		return "repositories"
+	case ByTotalRepoSize:
+		return "total repo size"
+	case ByLargeMonorepos:
+		return "large monorepos"
+	case ByLargestRepoSize:
+		return "largest repo size"
+	case ByLargestIndexSize:
+		return "largest index size"
+	case ByUserRepoSumRatio:
+		return "users and repositories"
+	case BySearchQPS:
+		return "search QPS"
+	case ByInsightSeriesRepos:
+		return "insight series"
+	case BySCIPUploads:
+		return "SCIP uploads"
+	case ByActiveSeries:
+		return "active series"
+	case ByTraceSampling:
+		return "trace sampling"
+	default:
+		return fmt.Sprintf("Factor(%d)", int(f))
+	}
+}
+
 type Service struct {
 	// Value corresponding to the scaling factor type (users, repositories, large monorepos, etc.)
 	Value float64 `json:"-"`
@@ -30,6 +67,13 @@ type Service struct {
 	NameInDocker, NameInK8s, PodName, Label string    `json:"-"`
 	// ContactSupport, when true, indicates that for the given value support should be contacted.
 	ContactSupport bool `json:"-"`
+	// SizedBy are the scaling factors the service was sized by.
+	SizedBy []Factor `json:"-"`
+	// Storage class, autoscaling and availability settings are only set in the Helm export.
+	StorageClassName          string                     `json:"storageClassName,omitempty"`
+	Autoscaling               *Autoscaling               `json:"autoscaling,omitempty"`
+	PodDisruptionBudget       *PodDisruptionBudgetValues `json:"podDisruptionBudget,omitempty"`
+	TopologySpreadConstraints []TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
 }
 type Resources struct {
 	Limits   Resource `json:"limits,omitempty"`
@@ -85,37 +129,93 @@ func addUnit(f float64, t string) string {
 	return fmt.Sprintf("%v%v", math.Trunc(f), t)
 }
 
-func (r *Service) join(o *Service) {
+// join fills in the replicas and resources of the service which are not set yet from o, and
+// reports whether o set any of them or the storage.
+func (r *Service) join(o *Service) bool {
+	set := false
 	r.Value = 0
 	r.Label = o.Label
 	r.NameInDocker = o.NameInDocker
 	r.PodName = o.PodName
-	if r.Replicas == 0 {
+	if r.Replicas == 0 && o.Replicas > 0 {
 		r.Replicas = o.Replicas
+		set = true
 	}
 	if r.Resources.Requests.CPU == 0 && r.Resources.Limits.CPU == 0 {
-		r.Resources.Requests.CPU = resourceRound(o.Resources.Requests.CPU)
-		r.Resources.Limits.CPU = resourceRound(o.Resources.Limits.CPU)
-		r.Resources.Requests.CPUS = strings.ToLower(addUnit(r.Resources.Requests.CPU, ""))
-		r.Resources.Limits.CPUS = strings.ToLower(addUnit(r.Resources.Limits.CPU, ""))
+		r.setCPU(o.Resources.Requests.CPU, o.Resources.Limits.CPU)
+		set = set || r.Resources.Requests.CPU > 0 || r.Resources.Limits.CPU > 0
 	}
 	if r.Resources.Requests.MEM == 0 && r.Resources.Limits.MEM == 0 {
-		r.Resources.Requests.MEM = resourceRound(o.Resources.Requests.MEM)
-		r.Resources.Limits.MEM = resourceRound(o.Resources.Limits.MEM)
-		r.Resources.Requests.MEMS = addUnit(r.Resources.Requests.MEM, "G")
-		r.Resources.Limits.MEMS = addUnit(r.Resources.Limits.MEM, "G")
+		r.setMemory(o.Resources.Requests.MEM, o.Resources.Limits.MEM)
+		set = set || r.Resources.Requests.MEM > 0 || r.Resources.Limits.MEM > 0
 	}
 	if o.Resources.Limits.EPH > 0 && r.Resources.Requests.EPH == 0 && r.Resources.Limits.EPH == 0 {
 		r.Resources.Requests.EPH = resourceRound(o.Resources.Requests.EPH)
 		r.Resources.Limits.EPH = resourceRound(o.Resources.Limits.EPH)
 		r.Resources.Requests.EPHS = addUnit(resourceRound(math.Floor(r.Resources.Requests.EPH/float64(r.Replicas))), "G")
 		r.Resources.Limits.EPHS = addUnit(resourceRound(r.Resources.Limits.EPH/float64(r.Replicas)), "G")
+		set = true
 	}
 	if o.Storage > 0 {
-		r.Storage = resourceRound(o.Storage)
-		r.PVC = addUnit(resourceRound(o.Storage), "Gi")
+		set = set || o.Storage != r.Storage
+		r.setStorage(o.Storage)
 	}
 	r.ContactSupport = r.ContactSupport || o.ContactSupport
+	return set
+}
+
+// raise raises the replicas and resources of the service to the ones of o where they are
+// higher, and reports whether anything was raised.
+func (r *Service) raise(o *Service) bool {
+	raised := false
+	if o.Replicas > r.Replicas {
+		r.Replicas = o.Replicas
+		if r.Resources.Limits.EPH > 0 {
+			// Ephemeral storage is shared by the replicas.
+			r.Resources.Requests.EPHS = addUnit(resourceRound(math.Floor(r.Resources.Requests.EPH/float64(r.Replicas))), "G")
+			r.Resources.Limits.EPHS = addUnit(resourceRound(r.Resources.Limits.EPH/float64(r.Replicas)), "G")
+		}
+		raised = true
+	}
+	if o.Resources.Requests.CPU > r.Resources.Requests.CPU || o.Resources.Limits.CPU > r.Resources.Limits.CPU {
+		r.setCPU(math.Max(r.Resources.Requests.CPU, o.Resources.Requests.CPU), math.Max(r.Resources.Limits.CPU, o.Resources.Limits.CPU))
+		raised = true
+	}
+	if o.Resources.Requests.MEM > r.Resources.Requests.MEM || o.Resources.Limits.MEM > r.Resources.Limits.MEM {
+		r.setMemory(math.Max(r.Resources.Requests.MEM, o.Resources.Requests.MEM), math.Max(r.Resources.Limits.MEM, o.Resources.Limits.MEM))
+		raised = true
+	}
+	r.ContactSupport = r.ContactSupport || o.ContactSupport
+	return raised
+}
+
+// sizedBy records that the service was sized by the scaling factor.
+func (r *Service) sizedBy(f Factor) {
+	for _, v := range r.SizedBy {
+		if v == f {
+			return
+		}
+	}
+	r.SizedBy = append(r.SizedBy, f)
+}
+
+func (r *Service) setCPU(request, limit float64) {
+	r.Resources.Requests.CPU = resourceRound(request)
+	r.Resources.Limits.CPU = resourceRound(limit)
+	r.Resources.Requests.CPUS = strings.ToLower(addUnit(r.Resources.Requests.CPU, ""))
+	r.Resources.Limits.CPUS = strings.ToLower(addUnit(r.Resources.Limits.CPU, ""))
+}
+
+func (r *Service) setMemory(request, limit float64) {
+	r.Resources.Requests.MEM = resourceRound(request)
+	r.Resources.Limits.MEM = resourceRound(limit)
+	r.Resources.Requests.MEMS = addUnit(r.Resources.Requests.MEM, "G")
+	r.Resources.Limits.MEMS = addUnit(r.Resources.Limits.MEM, "G")
+}
+
+func (r *Service) setStorage(storage float64) {
+	r.Storage = resourceRound(storage)
+	r.PVC = addUnit(r.Storage, "Gi")
 }
 
 func (d DockerResources) join(o *Service) DockerResources {
@@ -151,14 +251,24 @@ var (
 	AverageRepositoriesRange = Range{1, 5000000}
 	UserRepoSumRatioRange    = Range{1, 5000}
 	EngagementRateRange      = Range{5, 100}
+	SearchQPSRange           = Range{0, 1000}
+	PeakConcurrencyRange     = Range{1, 100}
+	MaxVolumeSizeRange       = Range{0, 65536}
+	HorizonMonthsRange       = Range{0, 36}
+	GrowthRateRange          = Range{0, 100}
+	InsightSeriesRange       = Range{0, 10000}
+	ReposPerSeriesRange      = Range{0, 5000000}
+	InsightSeriesReposRange  = Range{0, 1000000000}
 )
 
 func init() {
 	// Ensure reference points are sorted by ascending value so it is easy for us to interpolate them.
-	for _, ref := range References {
-		sort.Slice(ref.ReferencePoints, func(i, j int) bool {
-			return ref.ReferencePoints[i].Value < ref.ReferencePoints[j].Value
-		})
+	for _, refs := range [][]ServiceScale{References, LoadReferences} {
+		for _, ref := range refs {
+			sort.Slice(ref.ReferencePoints, func(i, j int) bool {
+				return ref.ReferencePoints[i].Value < ref.ReferencePoints[j].Value
+			})
+		}
 	}
 }
 
@@ -230,18 +340,61 @@ type Estimate struct {
 	DeploymentType            string // calculated if set to "docker-compose"
 	RecommendedDeploymentType string
 	CodeInsight               string // If Code Insight is enabled
-	EngagementRate            int    // The percentage of users who use Sourcegraph regularly.
+	EngagementRate            int    // The percentage of users who use Sourcegraph regularly, 0 for all of them.
+	PeakConcurrency           int    // The percentage of engaged users active at the same time at peak, 0 for the default
+	PeakSearchQPS             int    // Expected search queries per second at peak, 0 to size search by users and repositories
+	AutomationHeavy           bool   // Most search traffic comes from the API and automation rather than users
 	Repositories              int    // Number of repositories
 	LargeMonorepos            int    // Number of monorepos - repos that are larger than 2GB (~50 times larger than the average size repo)
 	LargestRepoSize           int    // Size of the largest repository in GB
 	LargestIndexSize          int    // Size of the largest SCIP index file in GB
 	TotalRepoSize             int    // Size of all repositories
 	Users                     int    // Number of users
+	HighAvailability          bool   // Run redundant replicas of stateless services (Kubernetes only)
+	GitserverMaxVolumeSize    int    // Maximum size of a gitserver volume in GB, 0 for no limit
+	SCIPUploadsPerDay         int    // Number of commits indexed a day, 0 to assume one upload of the largest index a day
+	AverageIndexSizeMB        int    // Average size of a SCIP index file in MB, 0 for the size of the largest one
+	SCIPLanguages             int    // Number of languages indexed, each uploaded separately, 0 for one
+	SCIPRetentionDays         int    // Number of days SCIP uploads other than the tip of the default branch are kept, 0 for the default
+	InsightSeries             int    // Number of code insights series, 0 for the default
+	ReposPerSeries            int    // Average number of repositories a code insights series runs over, 0 for all of them
+	InsightsRetentionMonths   int    // Number of months code insights data points are kept, 0 for the default
+	PrometheusRetentionDays   int    // Number of days Prometheus keeps metrics, 0 for the default
+	ScrapeIntervalSeconds     int    // Interval at which Prometheus scrapes metrics, 0 for the default
+	ActiveSeries              int    // Number of active Prometheus series, 0 to estimate it from the replicas
+	ObservabilityMode         string // "bundled" monitoring stack (the default), "external" stack or "disabled"
+	CloneThroughputMBps       int    // Network throughput from the code hosts to each gitserver replica in MB/s, 0 for the default
+	DiskThroughputMBps        int    // Write throughput of a gitserver volume in MB/s, 0 for the default
+	TraceSamplingRate         int    // The percentage of requests traced, 0 for only the requests which ask for it
+	RepoFetchIntervalMinutes  int    // Average interval at which repositories are fetched from the code hosts, 0 for the default
+	BackupFrequencyHours      int    // Interval between snapshots of the stateful services in hours, 0 for the default
+	BackupRetentionCount      int    // Number of snapshots kept, 0 for the default
+	WarmStandby               string // Warm-standby region: "none" (the default), "full" or "reduced"
+	StagingRepositoryPercent  int    // Share of the repositories mirrored to staging, 0 for the default
+	StagingUserPercent        int    // Share of the users who use staging, 0 for the default
+	QuotaHeadroomPercent      int    // Room the namespace quota leaves above the estimate, 0 for the default
+
+	// Optional shape of the corpus. When set, the repository count, total and largest repository
+	// size and number of monorepos are derived from it.
+	RepoSizeDistribution *RepoSizeDistribution
+	// Optional code of the largest repositories. When set, LargestIndexSize is predicted from it.
+	IndexSizeRepositories []RepositoryCode
+	// Optional growth of the instance over a planning horizon, see Projection.
+	Growth *Growth
 
 	// calculated results
-	AverageRepositories int                        // Number of total repositories including monorepos: number repos + monorepos x 50
+	AverageRepositories int                        // Number of total repositories including monorepos: number repos + monorepos x 50, or the effective repositories of the size distribution
 	ContactSupport      bool                       // Contact support required
+	GitserverShards     GitserverShardPlan         // Distribution of repositories across gitserver replicas
+	IndexedSearch       IndexedSearchPlan          // Size of the zoekt index and its distribution across replicas
+	Databases           DatabasePlan               // Storage required by the databases for the workload
+	CodeIntel           CodeIntelPlan              // Volume of SCIP uploads and the capacity to process and keep them
+	PredictedIndexSizes []IndexSizePrediction      // Predicted SCIP index size of the repositories, largest first
+	Observability       ObservabilityPlan          // Metrics collected by the monitoring stack and the capacity to keep them
+	Rollout             RolloutPlan                // How long the initial clone and indexing take
+	Storage             StoragePlan                // IOPS and throughput of the volumes, and the traffic to the code hosts
 	EngagedUsers        int                        // Number of users x engagement rate
+	PeakUsers           int                        // Number of engaged users x peak concurrency
 	Services            map[string]Service         // List of services output
 	DockerServices      map[string]DockerResources // List of services output for docker compose
 	UserRepoSumRatio    int                        // The ratio used to determine deployment size:  (user count + average repos count) / 1000
@@ -253,35 +406,63 @@ type Estimate struct {
 	TotalCPU, TotalMemoryGB, TotalStorageSize int
 
 	TotalSharedCPU, TotalSharedMemoryGB int
+
+	// The additional vCPUs and memory included in the totals for high availability.
+	HighAvailabilityCPU, HighAvailabilityMemoryGB int
+
+	// minReplicas are the replicas a derived environment keeps of each service, and staging is
+	// true for the staging environment.
+	minReplicas map[string]int
+	staging     bool
+	// namespace is the namespace of the instance in a cluster, set on the manifests it exports.
+	namespace string
+}
+
+// factorValue returns the value of the estimate's input corresponding to the scaling factor.
+func (e *Estimate) factorValue(f Factor) float64 {
+	switch f {
+	case ByEngagedUsers:
+		return e.engagedUsersLoad()
+	case ByAverageRepositories:
+		return float64(e.AverageRepositories)
+	case ByLargeMonorepos:
+		return float64(e.LargeMonorepos)
+	case ByLargestRepoSize:
+		return float64(e.LargestRepoSize)
+	case ByLargestIndexSize:
+		return float64(e.LargestIndexSize)
+	case ByUserRepoSumRatio:
+		return float64(e.UserRepoSumRatio)
+	case ByTotalRepoSize:
+		return float64(e.TotalRepoSize)
+	case BySearchQPS:
+		return e.searchLoad()
+	case ByInsightSeriesRepos:
+		return e.insightSeriesRepos()
+	case BySCIPUploads:
+		return e.CodeIntel.UploadsPerDay
+	case ByActiveSeries:
+		return float64(e.Observability.Series)
+	case ByTraceSampling:
+		return e.Observability.SpansPerSecond
+	default:
+		panic("never here")
+	}
 }
 
 func (e *Estimate) Calculate() *Estimate {
-	e.EngagedUsers = e.Users
-	e.UserRepoSumRatio = (e.Users + e.Repositories + e.LargeMonorepos*MonorepoFactor) / 1000
-	e.AverageRepositories = e.Repositories + e.LargeMonorepos*MonorepoFactor
+	// Derived estimates copy a calculated one, so only this calculation may ask to contact support.
+	e.ContactSupport = false
+	e.predictIndexSize()
+	e.applyEngagement()
+	e.applyRepoSizeDistribution()
+	e.UserRepoSumRatio = (e.EngagedUsers + e.AverageRepositories) / 1000
+	e.planCodeIntel()
+	e.planDatabases()
 	e.Services = make(map[string]Service)
 	e.DockerServices = make(map[string]DockerResources)
 	for _, ref := range References {
-		var value float64
-		switch ref.ScalingFactor {
-		case ByEngagedUsers:
-			value = float64(e.Users)
-		case ByAverageRepositories:
-			value = float64(e.AverageRepositories)
-		case ByLargeMonorepos:
-			value = float64(e.LargeMonorepos)
-		case ByLargestRepoSize:
-			value = float64(e.LargestRepoSize)
-		case ByLargestIndexSize:
-			value = float64(e.LargestIndexSize)
-		case ByUserRepoSumRatio:
-			value = float64(e.UserRepoSumRatio)
-		case ByTotalRepoSize:
-			value = float64(e.TotalRepoSize)
-		default:
-			panic("never here")
-		}
-		v := interpolateReferencePoints(ref.ReferencePoints, value)
+		v := interpolateReferencePoints(ref.ReferencePoints, e.factorValue(ref.ScalingFactor))
 		if v.ContactSupport {
 			e.ContactSupport = true
 		}
@@ -289,35 +470,62 @@ func (e *Estimate) Calculate() *Estimate {
 		v.PodName = ref.PodName
 		v.NameInDocker = ref.DockerServiceName
 		switch ref.ServiceName {
+		case "pgsql":
+			v.Storage = databaseStorage(e.Databases.PgsqlGB)
 		case "codeinsights-db":
 			if e.CodeInsight != "Enable" {
 				v.Storage = float64(0)
+			} else {
+				v.Storage = databaseStorage(e.Databases.CodeInsightsGB)
 			}
 		case "codeintel-db":
 			if e.LargestIndexSize == 0 {
 				v.Storage = float64(0)
+			} else {
+				v.Storage = databaseStorage(e.Databases.CodeIntelGB)
 			}
 		case "searcher":
 			// MAX(Size of Largest + Size of All * 0.15, Size of All * 0.3)
-			v.Resources.Requests.EPH = math.Max(float64(e.LargestRepoSize)+float64(e.TotalRepoSize)*0.15, float64(e.TotalRepoSize)*0.3)
-			v.Resources.Limits.EPH = math.Max(float64(e.LargestRepoSize)+float64(e.TotalRepoSize)*0.3, float64(e.TotalRepoSize)*0.4)
-		case "gitserver":
-			// 30% More than the total repo size
-			v.Storage = float64(e.TotalRepoSize * 130 / 100)
+			if v.Resources.Limits.EPH > 0 {
+				v.Resources.Requests.EPH = math.Max(e.hotRepoSize()+float64(e.TotalRepoSize)*0.15, float64(e.TotalRepoSize)*0.3)
+				v.Resources.Limits.EPH = math.Max(e.hotRepoSize()+float64(e.TotalRepoSize)*0.3, float64(e.TotalRepoSize)*0.4)
+			}
+		case "symbols":
+			if v.Resources.Limits.EPH > 0 {
+				v.Resources.Requests.EPH = math.Max(v.Resources.Requests.EPH, e.hotRepoSize()*1.2)
+				v.Resources.Limits.EPH = math.Max(v.Resources.Limits.EPH, e.hotRepoSize()*1.5)
+			}
 		case "blobstore":
-			v.Storage = float64(e.LargestIndexSize)
-		case "indexedSearch":
-			v.Storage = float64(e.TotalRepoSize * 120 / 100 / 2)
+			v.Storage = e.CodeIntel.BlobstoreGB
 		}
 		r := e.Services[ref.ServiceName]
-		(&r).join(&v)
+		if r.join(&v) {
+			r.sizedBy(ref.ScalingFactor)
+		}
 		e.Services[ref.ServiceName] = r
-		// create struct for docker-compose yaml file
-		e.DockerServices[ref.DockerServiceName] = e.DockerServices[ref.ServiceName].join(&r)
 	}
+	// Search and code insights load raise the services above what users and repositories require.
+	for _, ref := range LoadReferences {
+		value := e.factorValue(ref.ScalingFactor)
+		if value == 0 {
+			continue
+		}
+		v := interpolateReferencePoints(ref.ReferencePoints, value)
+		if v.ContactSupport {
+			e.ContactSupport = true
+		}
+		r := e.Services[ref.ServiceName]
+		if r.raise(&v) {
+			r.sizedBy(ref.ScalingFactor)
+		}
+		e.Services[ref.ServiceName] = r
+	}
+	e.applyCodeIntelPlan()
 	if e.DeploymentType == "type" {
 		e.DeploymentType = "kubernetes"
 	}
+	e.planIndexedSearch()
+	e.applyMinReplicas()
 	// Ensure we have the same replica counts for services that live in the
 	// same pod.
 	for _, pod := range pods {
@@ -333,6 +541,18 @@ func (e *Estimate) Calculate() *Estimate {
 			e.Services[name] = v
 		}
 	}
+	e.planGitserverShards()
+	var haCPU, haMemoryGB float64
+	if e.HighAvailability && e.DeploymentType == "kubernetes" {
+		haCPU, haMemoryGB = e.applyHighAvailability()
+	}
+	e.planObservability()
+	e.planRollout()
+	e.planStorage()
+	// create struct for docker-compose yaml file
+	for _, r := range e.Services {
+		e.DockerServices[r.NameInDocker] = DockerResources{}.join(&r)
+	}
 	var (
 		sumCPURequests, sumCPULimits, sumMemoryGBRequests, sumMemoryGBLimits, sumStorageSize float64
 		largestCPULimit, largestMemoryGBLimit                                                float64
@@ -343,7 +563,8 @@ func (e *Estimate) Calculate() *Estimate {
 			return
 		}
 		visited[service] = struct{}{}
-		sumStorageSize += ref.Storage
+		// Every replica has its own volume.
+		sumStorageSize += ref.Storage * math.Max(float64(ref.Replicas), 1)
 		sumCPURequests += ref.Resources.Requests.CPU
 		sumCPULimits += ref.Resources.Limits.CPU
 		sumMemoryGBRequests += ref.Resources.Requests.MEM
@@ -360,6 +581,9 @@ func (e *Estimate) Calculate() *Estimate {
 		countRef(service, &r)
 	}
 	for service := range defaults {
+		if !e.deployed(service) {
+			continue
+		}
 		r := defaults[service][e.DeploymentType]
 		countRef(service, &r)
 	}
@@ -412,6 +636,17 @@ func (e *Estimate) Calculate() *Estimate {
 		e.InstanceSize = "3XL"
 		e.RecommendedDeploymentType = "Kubernetes with auto-scaling enabled"
 	}
+	if e.HighAvailability && e.DeploymentType == "kubernetes" {
+		// Reserve room to reschedule the largest pod while a node is drained.
+		e.HighAvailabilityCPU = int(math.Ceil(haCPU + largestCPULimit))
+		e.HighAvailabilityMemoryGB = int(math.Ceil(haMemoryGB + largestMemoryGBLimit))
+		e.TotalCPU += e.HighAvailabilityCPU
+		e.TotalMemoryGB += e.HighAvailabilityMemoryGB
+		// Machine images run on a single host, which cannot be highly available.
+		if e.RecommendedDeploymentType == "Sourcegraph Machine Images" {
+			e.RecommendedDeploymentType = "Kubernetes"
+		}
+	}
 	e.TotalStorageSize = int(math.Ceil(sumStorageSize))
 	e.TotalSharedCPU = int(math.Ceil(largestCPULimit))
 	e.TotalSharedMemoryGB = int(math.Ceil(largestMemoryGBLimit))
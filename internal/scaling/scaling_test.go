@@ -40,6 +40,354 @@ func TestEstimate(t *testing.T) {
 			EngagementRate:   100,
 			CodeInsight:      "Enable",
 		},
+	}, {
+		Name: "kubernetes",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     300000,
+			TotalRepoSize:    12000,
+			LargestRepoSize:  50,
+			LargestIndexSize: 10,
+			Users:            30000,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+		},
+	}, {
+		Name: "high-availability",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     3000,
+			TotalRepoSize:    100,
+			LargestRepoSize:  5,
+			LargestIndexSize: 1,
+			Users:            300,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+			HighAvailability: true,
+		},
+	}, {
+		Name: "gitserver-shards",
+		Estimate: scaling.Estimate{
+			DeploymentType:         "kubernetes",
+			Repositories:           20000,
+			TotalRepoSize:          5000,
+			LargestRepoSize:        100,
+			LargestIndexSize:       1,
+			Users:                  1000,
+			EngagementRate:         100,
+			CodeInsight:            "Enable",
+			GitserverMaxVolumeSize: 1000,
+		},
+	}, {
+		Name: "gitserver-volume-too-small",
+		Estimate: scaling.Estimate{
+			DeploymentType:         "kubernetes",
+			Repositories:           2000,
+			TotalRepoSize:          2000,
+			LargestRepoSize:        600,
+			LargestIndexSize:       1,
+			Users:                  1000,
+			EngagementRate:         100,
+			CodeInsight:            "Enable",
+			GitserverMaxVolumeSize: 500,
+		},
+	}, {
+		Name: "engagement",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     5000,
+			TotalRepoSize:    500,
+			LargestRepoSize:  10,
+			LargestIndexSize: 1,
+			Users:            20000,
+			EngagementRate:   30,
+			PeakConcurrency:  40,
+			CodeInsight:      "Enable",
+		},
+	}, {
+		Name: "search-load",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     20000,
+			TotalRepoSize:    2000,
+			LargestRepoSize:  20,
+			LargestIndexSize: 1,
+			Users:            2000,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+			PeakSearchQPS:    100,
+			AutomationHeavy:  true,
+		},
+	}, {
+		Name: "code-insights",
+		Estimate: scaling.Estimate{
+			DeploymentType:          "kubernetes",
+			Repositories:            50000,
+			TotalRepoSize:           5000,
+			LargestRepoSize:         20,
+			LargestIndexSize:        1,
+			Users:                   3000,
+			EngagementRate:          100,
+			CodeInsight:             "Enable",
+			InsightSeries:           2000,
+			ReposPerSeries:          20000,
+			InsightsRetentionMonths: 36,
+		},
+	}, {
+		Name: "scip-uploads",
+		Estimate: scaling.Estimate{
+			DeploymentType:     "kubernetes",
+			Repositories:       2000,
+			TotalRepoSize:      3000,
+			LargestRepoSize:    100,
+			LargestIndexSize:   40,
+			Users:              2000,
+			EngagementRate:     100,
+			CodeInsight:        "Disable",
+			SCIPUploadsPerDay:  100,
+			AverageIndexSizeMB: 8000,
+			SCIPLanguages:      3,
+			SCIPRetentionDays:  14,
+		},
+	}, {
+		Name: "index-size-prediction",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     10000,
+			TotalRepoSize:    1500,
+			LargestRepoSize:  60,
+			LargestIndexSize: 1,
+			Users:            1500,
+			EngagementRate:   100,
+			CodeInsight:      "Disable",
+			IndexSizeRepositories: []scaling.RepositoryCode{
+				{Name: "monorepo", LinesOfCode: 40000000, Language: "c++"},
+				{Name: "backend", LinesOfCode: 8000000, Language: "Go"},
+				{Name: "frontend", LinesOfCode: 3000000, Language: "typescript"},
+				{Name: "tools", LinesOfCode: 500000, Language: "perl"},
+			},
+		},
+	}, {
+		Name: "index-size-given",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     2000,
+			TotalRepoSize:    300,
+			LargestRepoSize:  20,
+			LargestIndexSize: 40,
+			Users:            1000,
+			EngagementRate:   100,
+			CodeInsight:      "Disable",
+			IndexSizeRepositories: []scaling.RepositoryCode{
+				{Name: "backend", LinesOfCode: 1000000, Language: "go"},
+			},
+		},
+	}, {
+		Name: "observability",
+		Estimate: scaling.Estimate{
+			DeploymentType:          "kubernetes",
+			Repositories:            100000,
+			TotalRepoSize:           10000,
+			LargestRepoSize:         50,
+			LargestIndexSize:        5,
+			Users:                   10000,
+			EngagementRate:          100,
+			CodeInsight:             "Enable",
+			PrometheusRetentionDays: 90,
+			ScrapeIntervalSeconds:   15,
+			ActiveSeries:            3000000,
+		},
+	}, {
+		Name: "tracing",
+		Estimate: scaling.Estimate{
+			DeploymentType:    "kubernetes",
+			Repositories:      20000,
+			TotalRepoSize:     2000,
+			LargestRepoSize:   20,
+			LargestIndexSize:  1,
+			Users:             20000,
+			EngagementRate:    100,
+			CodeInsight:       "Enable",
+			TraceSamplingRate: 20,
+		},
+	}, {
+		Name: "observability-external",
+		Estimate: scaling.Estimate{
+			DeploymentType:    "kubernetes",
+			Repositories:      20000,
+			TotalRepoSize:     2000,
+			LargestRepoSize:   20,
+			LargestIndexSize:  1,
+			Users:             20000,
+			EngagementRate:    100,
+			CodeInsight:       "Enable",
+			ObservabilityMode: "external",
+			TraceSamplingRate: 20,
+		},
+	}, {
+		Name: "observability-disabled",
+		Estimate: scaling.Estimate{
+			DeploymentType:    "docker-compose",
+			Repositories:      2000,
+			TotalRepoSize:     200,
+			LargestRepoSize:   5,
+			LargestIndexSize:  1,
+			Users:             500,
+			EngagementRate:    100,
+			CodeInsight:       "Enable",
+			ObservabilityMode: "disabled",
+		},
+	}, {
+		Name: "rollout",
+		Estimate: scaling.Estimate{
+			DeploymentType:      "kubernetes",
+			Repositories:        200000,
+			TotalRepoSize:       20000,
+			LargestRepoSize:     50,
+			LargestIndexSize:    1,
+			Users:               5000,
+			EngagementRate:      100,
+			CodeInsight:         "Disable",
+			CloneThroughputMBps: 50,
+			DiskThroughputMBps:  500,
+		},
+	}, {
+		Name: "storage-performance",
+		Estimate: scaling.Estimate{
+			DeploymentType:           "kubernetes",
+			Repositories:             100000,
+			TotalRepoSize:            10000,
+			LargestRepoSize:          50,
+			LargestIndexSize:         1,
+			Users:                    20000,
+			EngagementRate:           50,
+			PeakSearchQPS:            50,
+			CodeInsight:              "Disable",
+			RepoFetchIntervalMinutes: 30,
+		},
+	}, {
+		Name: "backups",
+		Estimate: scaling.Estimate{
+			DeploymentType:       "kubernetes",
+			Repositories:         50000,
+			TotalRepoSize:        5000,
+			LargestRepoSize:      50,
+			LargestIndexSize:     5,
+			Users:                5000,
+			EngagementRate:       50,
+			CodeInsight:          "Enable",
+			HighAvailability:     true,
+			BackupFrequencyHours: 6,
+			BackupRetentionCount: 28,
+			WarmStandby:          "reduced",
+		},
+	}, {
+		Name: "staging",
+		Estimate: scaling.Estimate{
+			DeploymentType:           "kubernetes",
+			Repositories:             100000,
+			TotalRepoSize:            10000,
+			LargestRepoSize:          50,
+			LargeMonorepos:           3,
+			LargestIndexSize:         5,
+			Users:                    20000,
+			EngagementRate:           50,
+			CodeInsight:              "Enable",
+			HighAvailability:         true,
+			StagingRepositoryPercent: 5,
+			StagingUserPercent:       2,
+		},
+	}, {
+		Name: "database-retention",
+		Estimate: scaling.Estimate{
+			DeploymentType:          "kubernetes",
+			Repositories:            500000,
+			TotalRepoSize:           20000,
+			LargestRepoSize:         50,
+			LargestIndexSize:        20,
+			Users:                   10000,
+			EngagementRate:          50,
+			CodeInsight:             "Enable",
+			SCIPRetentionDays:       30,
+			InsightsRetentionMonths: 24,
+		},
+	}, {
+		Name: "growth",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     4000,
+			TotalRepoSize:    400,
+			LargestRepoSize:  10,
+			LargestIndexSize: 2,
+			Users:            800,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+			Growth: &scaling.Growth{
+				RepositoriesPerMonth: 500,
+				RepoSizeGrowth:       3,
+				UsersPerMonth:        50,
+				IndexGrowth:          5,
+				HorizonMonths:        18,
+				IntervalMonths:       4,
+			},
+		},
+	}, {
+		Name: "growth-distribution",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			LargestIndexSize: 2,
+			Users:            800,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+			RepoSizeDistribution: &scaling.RepoSizeDistribution{
+				Buckets: []scaling.RepoSizeBucket{
+					{MaxSizeGB: 0.2, Count: 4000},
+				},
+			},
+			Growth: &scaling.Growth{
+				RepositoriesPerMonth: 2000,
+				RepoSizeGrowth:       2,
+				HorizonMonths:        12,
+				IntervalMonths:       4,
+			},
+		},
+	}, {
+		Name: "size-distribution",
+		Estimate: scaling.Estimate{
+			DeploymentType:         "kubernetes",
+			LargestIndexSize:       1,
+			Users:                  1000,
+			EngagementRate:         100,
+			CodeInsight:            "Enable",
+			GitserverMaxVolumeSize: 1000,
+			RepoSizeDistribution: &scaling.RepoSizeDistribution{
+				Buckets: []scaling.RepoSizeBucket{
+					{MaxSizeGB: 0.01, Count: 15000},
+					{MaxSizeGB: 0.1, Count: 4000},
+					{MaxSizeGB: 1, Count: 900},
+					{MaxSizeGB: 10, Count: 60},
+				},
+				TopRepositories: []float64{60, 40, 25, 15},
+			},
+		},
+	}, {
+		Name: "size-distribution-missing",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     30000,
+			LargestIndexSize: 1,
+			Users:            1000,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+			RepoSizeDistribution: &scaling.RepoSizeDistribution{
+				Buckets: []scaling.RepoSizeBucket{
+					{MaxSizeGB: 0.01, Count: 15000},
+					{MaxSizeGB: 0.1, Count: 4000},
+					{MaxSizeGB: 1, Count: 900},
+				},
+				TopRepositories: []float64{8, 20},
+			},
+		},
 	}}
 
 	for _, tc := range cases {
@@ -50,6 +398,159 @@ func TestEstimate(t *testing.T) {
 	}
 }
 
+func TestTerraformExport(t *testing.T) {
+	cases := []struct {
+		Name string
+		scaling.Estimate
+	}{{
+		Name: "docker-compose",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "docker-compose",
+			Repositories:     300,
+			TotalRepoSize:    30,
+			LargestRepoSize:  1,
+			LargestIndexSize: 1,
+			Users:            100,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+		},
+	}, {
+		Name: "kubernetes",
+		Estimate: scaling.Estimate{
+			DeploymentType:   "kubernetes",
+			Repositories:     50000,
+			TotalRepoSize:    2000,
+			LargestRepoSize:  20,
+			LargestIndexSize: 4,
+			Users:            5000,
+			EngagementRate:   100,
+			CodeInsight:      "Enable",
+		},
+	}}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			e := tc.Estimate.Calculate()
+			autogold.Equal(t, e.TerraformExport()+"\n"+e.TerraformJSONExport())
+		})
+	}
+}
+
+func TestHPAExport(t *testing.T) {
+	e := (&scaling.Estimate{
+		DeploymentType:   "kubernetes",
+		Repositories:     300000,
+		TotalRepoSize:    12000,
+		LargestRepoSize:  50,
+		LargestIndexSize: 10,
+		Users:            30000,
+		EngagementRate:   100,
+		CodeInsight:      "Enable",
+	}).Calculate()
+	autogold.Equal(t, e.HPAExport())
+}
+
+func TestHelmExport(t *testing.T) {
+	e := (&scaling.Estimate{
+		DeploymentType:    "kubernetes",
+		Repositories:      20000,
+		TotalRepoSize:     2000,
+		LargestRepoSize:   20,
+		LargestIndexSize:  1,
+		Users:             2000,
+		EngagementRate:    100,
+		CodeInsight:       "Enable",
+		ObservabilityMode: "disabled",
+	}).Calculate()
+	autogold.Equal(t, e.HelmExport())
+}
+
+func TestStagingExport(t *testing.T) {
+	// Production is beyond the largest reference points, staging is not.
+	e := (&scaling.Estimate{
+		DeploymentType:   "kubernetes",
+		Repositories:     20000,
+		TotalRepoSize:    2000,
+		LargestRepoSize:  20,
+		LargestIndexSize: 1,
+		Users:            100000,
+		EngagementRate:   100,
+		CodeInsight:      "Enable",
+	}).Calculate()
+	autogold.Equal(t, string(e.Staging().MarkdownExport()))
+}
+
+func TestCluster(t *testing.T) {
+	c := scaling.Cluster{Instances: []scaling.ClusterInstance{{
+		Namespace: "payments",
+		Estimate: &scaling.Estimate{
+			Repositories:     5000,
+			TotalRepoSize:    500,
+			LargestRepoSize:  10,
+			LargestIndexSize: 2,
+			Users:            1000,
+			EngagementRate:   50,
+			CodeInsight:      "Disable",
+		},
+	}, {
+		Namespace: "platform",
+		Estimate: &scaling.Estimate{
+			Repositories:     50000,
+			TotalRepoSize:    5000,
+			LargestRepoSize:  50,
+			LargestIndexSize: 5,
+			Users:            5000,
+			EngagementRate:   50,
+			CodeInsight:      "Enable",
+			HighAvailability: true,
+		},
+	}, {
+		Namespace: "research",
+		Estimate: &scaling.Estimate{
+			Repositories:      1000,
+			TotalRepoSize:     100,
+			LargestRepoSize:   5,
+			Users:             200,
+			EngagementRate:    100,
+			CodeInsight:       "Disable",
+			ObservabilityMode: "external",
+		},
+	}}}
+	autogold.Equal(t, string(c.Calculate().MarkdownExport()))
+}
+
+func TestQuotaExport(t *testing.T) {
+	e := (&scaling.Estimate{
+		DeploymentType:       "kubernetes",
+		Repositories:         50000,
+		TotalRepoSize:        5000,
+		LargestRepoSize:      50,
+		LargestIndexSize:     5,
+		Users:                5000,
+		EngagementRate:       50,
+		CodeInsight:          "Enable",
+		QuotaHeadroomPercent: 30,
+	}).Calculate()
+	autogold.Equal(t, e.QuotaExport())
+}
+
+func TestClusterQuotaExport(t *testing.T) {
+	c := scaling.Cluster{Instances: []scaling.ClusterInstance{{
+		Namespace: "payments",
+		Estimate: &scaling.Estimate{
+			Repositories:     5000,
+			TotalRepoSize:    500,
+			LargestRepoSize:  10,
+			LargestIndexSize: 2,
+			Users:            1000,
+			EngagementRate:   50,
+			CodeInsight:      "Disable",
+		},
+	}}}
+	r := c.Calculate()
+	autogold.Equal(t, r.Instances[0].Estimate.QuotaExport())
+}
+
 // This test will ensure that the outputs of calculate don't break any known
 // invariants we expect. We do a mix of random inputs and some exhaustive
 // checks.
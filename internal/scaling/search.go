@@ -0,0 +1,24 @@
+package scaling
+
+const (
+	// At peak, a user runs a search about every 50 seconds.
+	searchesPerPeakUserPerSecond = 0.02
+
+	// Searches from the API and automation are more expensive than the ones users run: they
+	// are more often exhaustive (count:all), unindexed or structural.
+	automationQueryCost = 2
+)
+
+// searchLoad is the value of the BySearchQPS scaling factor: the peak search QPS, weighted by how
+// expensive the queries are. It is 0 unless the peak search QPS is given or the instance is
+// automation heavy, in which case the search QPS is estimated from the peak concurrent users.
+func (e *Estimate) searchLoad() float64 {
+	qps := float64(e.PeakSearchQPS)
+	if qps == 0 && e.AutomationHeavy {
+		qps = float64(e.PeakUsers) * searchesPerPeakUserPerSecond
+	}
+	if e.AutomationHeavy {
+		qps *= automationQueryCost
+	}
+	return qps
+}
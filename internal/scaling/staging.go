@@ -0,0 +1,173 @@
+package scaling
+
+import "math"
+
+const (
+	// DefaultStagingRepositoryPercent is the share of the repositories mirrored to staging, and
+	// DefaultStagingUserPercent the share of the users who use it.
+	DefaultStagingRepositoryPercent = 10
+	DefaultStagingUserPercent       = 5
+
+	// stagingMinReplicas is the most replicas staging keeps of a service production replicates, so
+	// that sharding and rolling updates behave like in production.
+	stagingMinReplicas = 2
+)
+
+var (
+	StagingRepositoryPercentRange = Range{0, 100}
+	StagingUserPercentRange       = Range{0, 100}
+)
+
+// The service classes staging is scaled down by: the services scaling with users and search
+// load, the services holding data, which scale with the repository subset, background workers,
+// and the monitoring stack.
+const (
+	ServiceClassStateless  = "stateless"
+	ServiceClassStateful   = "stateful"
+	ServiceClassBackground = "background"
+	ServiceClassMonitoring = "monitoring"
+)
+
+var serviceClasses = map[string]string{
+	"gitserver":            ServiceClassStateful,
+	"indexedSearch":        ServiceClassStateful,
+	"indexedSearchIndexer": ServiceClassStateful,
+	"pgsql":                ServiceClassStateful,
+	"codeintel-db":         ServiceClassStateful,
+	"codeinsights-db":      ServiceClassStateful,
+	"blobstore":            ServiceClassStateful,
+	"redisCache":           ServiceClassStateful,
+	"redisStore":           ServiceClassStateful,
+	"prometheus":           ServiceClassMonitoring,
+	"grafana":              ServiceClassMonitoring,
+	"jaeger":               ServiceClassMonitoring,
+	"otel-collector":       ServiceClassMonitoring,
+	"cadvisor":             ServiceClassMonitoring,
+}
+
+func serviceClass(service string) string {
+	for _, s := range statelessServices {
+		if s.Service == service {
+			return ServiceClassStateless
+		}
+	}
+	if class, ok := serviceClasses[service]; ok {
+		return class
+	}
+	return ServiceClassBackground
+}
+
+// ServiceClassScale is the resources of a service class in production and in staging.
+type ServiceClassScale struct {
+	Class               string
+	Production, Staging ClassResources
+}
+
+// ClassResources is the replicas and resource limits of the services of a class, across all
+// their replicas.
+type ClassResources struct {
+	Replicas                 int
+	CPU, MemoryGB, StorageGB float64
+}
+
+func classResources(services map[string]Service, class string) ClassResources {
+	var r ClassResources
+	for name, v := range services {
+		if serviceClass(name) != class {
+			continue
+		}
+		replicas := math.Max(float64(v.Replicas), 1)
+		r.Replicas += int(replicas)
+		r.CPU += v.Resources.Limits.CPU * replicas
+		r.MemoryGB += v.Resources.Limits.MEM * replicas
+		r.StorageGB += v.Storage * replicas
+	}
+	return r
+}
+
+func (e *Estimate) stagingRepositoryPercent() int {
+	if e.StagingRepositoryPercent <= 0 {
+		return DefaultStagingRepositoryPercent
+	}
+	return e.StagingRepositoryPercent
+}
+
+func (e *Estimate) stagingUserPercent() int {
+	if e.StagingUserPercent <= 0 {
+		return DefaultStagingUserPercent
+	}
+	return e.StagingUserPercent
+}
+
+// Staging returns the estimate of the staging environment of a calculated production estimate.
+// It mirrors a representative subset of the repositories, which still includes the largest
+// repository and monorepo, serves a share of the users without high availability, and keeps up
+// to 2 replicas of the services production replicates.
+func (e *Estimate) Staging() *Estimate {
+	repos := float64(e.stagingRepositoryPercent()) / 100
+	users := float64(e.stagingUserPercent()) / 100
+	subset := func(v int) int {
+		if v == 0 {
+			return 0
+		}
+		return int(math.Ceil(float64(v) * repos))
+	}
+
+	s := *e
+	s.Growth = nil
+	s.WarmStandby = ""
+	s.HighAvailability = false
+	s.staging = true
+	s.Users = int(math.Ceil(float64(e.Users) * users))
+	s.PeakSearchQPS = int(math.Ceil(float64(e.PeakSearchQPS) * users))
+	s.Repositories = subset(e.Repositories)
+	s.TotalRepoSize = int(math.Max(float64(subset(e.TotalRepoSize)), float64(e.LargestRepoSize)))
+	s.LargeMonorepos = int(math.Min(float64(e.LargeMonorepos), 1))
+	s.SCIPUploadsPerDay = subset(e.SCIPUploadsPerDay)
+	s.InsightSeries = subset(e.InsightSeries)
+	if d := e.RepoSizeDistribution; d != nil {
+		scaled := &RepoSizeDistribution{}
+		for _, b := range d.Buckets {
+			scaled.Buckets = append(scaled.Buckets, RepoSizeBucket{MaxSizeGB: b.MaxSizeGB, Count: subset(b.Count)})
+		}
+		if len(d.TopRepositories) > 0 {
+			// TopRepositories may not be sorted.
+			scaled.TopRepositories = []float64{d.sum(1)}
+		}
+		s.RepoSizeDistribution = scaled
+	}
+	if e.DeploymentType == "kubernetes" {
+		s.minReplicas = map[string]int{}
+		for name, service := range e.Services {
+			if service.Replicas > 1 {
+				s.minReplicas[name] = int(math.Min(float64(service.Replicas), stagingMinReplicas))
+			}
+		}
+	}
+	return s.Calculate()
+}
+
+// applyMinReplicas raises the services to the replicas a derived environment keeps.
+func (e *Estimate) applyMinReplicas() {
+	for name, replicas := range e.minReplicas {
+		v, ok := e.Services[name]
+		if !ok || v.Replicas >= replicas {
+			continue
+		}
+		v.Replicas = replicas
+		e.Services[name] = v
+	}
+}
+
+// StagingScales compares the resources of every service class in production and in staging: the
+// scale-down factor of each class.
+func (e *Estimate) StagingScales(staging *Estimate) []ServiceClassScale {
+	var scales []ServiceClassScale
+	for _, class := range []string{ServiceClassStateless, ServiceClassStateful, ServiceClassBackground, ServiceClassMonitoring} {
+		c := ServiceClassScale{Class: class, Production: classResources(e.Services, class), Staging: classResources(staging.Services, class)}
+		if c.Production.Replicas > 0 {
+			scales = append(scales, c)
+		}
+	}
+	return scales
+}
@@ -0,0 +1,138 @@
+package scaling
+
+import "math"
+
+const (
+	// DefaultRepoFetchIntervalMinutes is how often repositories are fetched from the code hosts
+	// on average. Recently changed repositories are fetched more often, and stale ones less.
+	DefaultRepoFetchIntervalMinutes = 120
+
+	// A fetch reads and writes about 50 IOs on gitserver, and transfers about 0.1% of the
+	// repository: most fetches find no or few new commits.
+	iopsPerFetch    = 50
+	fetchDeltaRatio = 0.001
+	// A search reads about 10 IOs on gitserver to resolve revisions. searcher caches the archive
+	// of a commit, so it only reads archives again after about 10% of the fetches, when new
+	// commits are searched.
+	gitserverIOPSPerSearch = 10
+	fetchArchiveRatio      = 0.1
+	// zoekt memory maps the index and serves searches from the page cache of the node, so its
+	// volume sees a flat baseline of about 100 IOPS besides reindexing.
+	zoektBaseIOPS = 100
+	// pgsql reads and writes about 16KB pages, with a baseline of 100 IOPS.
+	pgsqlBaseIOPS           = 100
+	pgsqlIOPSPerEngagedUser = 0.2
+	pgsqlIOPSPerRepository  = 0.002
+	pgsqlPageKB             = 16
+)
+
+// Volume performance classes, by the IOPS and throughput a volume needs, and the storage class
+// recommended for each. Low volumes can use standard disks, medium ones need SSDs, and high ones
+// SSDs with provisioned IOPS or throughput.
+const (
+	VolumeClassLow    = "low"
+	VolumeClassMedium = "medium"
+	VolumeClassHigh   = "high"
+
+	StorageClassStandard    = "standard"
+	StorageClassSSD         = "ssd"
+	StorageClassProvisioned = "provisioned"
+)
+
+var volumeClasses = []struct {
+	Class      string
+	IOPS, MBps float64
+}{
+	{VolumeClassLow, 500, 50},
+	{VolumeClassMedium, 3000, 125},
+}
+
+var RepoFetchIntervalRange = Range{0, 1440}
+
+// VolumePerformance is the IOPS and throughput required by each volume of a service.
+type VolumePerformance struct {
+	Service        string
+	IOPS           float64
+	ThroughputMBps float64
+	Class          string
+	StorageClass   string
+}
+
+// StoragePlan is the performance required by the volumes, and the traffic to the code hosts.
+type StoragePlan struct {
+	Volumes          []VolumePerformance
+	FetchesPerSecond float64
+	// EgressGBPerDay is the traffic from the code hosts to keep repositories up to date, once
+	// they are cloned.
+	EgressGBPerDay float64
+}
+
+// StorageClasses returns the recommended storage class of the volumes by service.
+func (p StoragePlan) StorageClasses() map[string]string {
+	classes := map[string]string{}
+	for _, v := range p.Volumes {
+		classes[v.Service] = v.StorageClass
+	}
+	return classes
+}
+
+func (e *Estimate) repoFetchIntervalMinutes() int {
+	if e.RepoFetchIntervalMinutes <= 0 {
+		return DefaultRepoFetchIntervalMinutes
+	}
+	return e.RepoFetchIntervalMinutes
+}
+
+// searchesPerSecond is the peak search QPS, given or estimated from the peak users.
+func (e *Estimate) searchesPerSecond() float64 {
+	if load := e.searchLoad(); load > 0 {
+		return load
+	}
+	return float64(e.PeakUsers) * searchesPerPeakUserPerSecond
+}
+
+func volumeClass(iops, mbps float64) (class, storageClass string) {
+	for _, c := range volumeClasses {
+		if iops <= c.IOPS && mbps <= c.MBps {
+			if c.Class == VolumeClassLow {
+				return c.Class, StorageClassStandard
+			}
+			return c.Class, StorageClassSSD
+		}
+	}
+	return VolumeClassHigh, StorageClassProvisioned
+}
+
+// planStorage estimates the IOPS and throughput of a gitserver, zoekt and pgsql volume from the
+// fetches, searches, reindexing and users, and recommends a storage class for them. It runs once
+// the replicas are known, as the load is spread across them.
+func (e *Estimate) planStorage() {
+	p := StoragePlan{}
+	repositories := math.Max(float64(e.Repositories+e.LargeMonorepos), 1)
+	averageRepoMB := float64(e.TotalRepoSize) * 1000 / repositories
+	p.FetchesPerSecond = repositories / float64(e.repoFetchIntervalMinutes()*60)
+	p.EgressGBPerDay = p.FetchesPerSecond * 24 * 60 * 60 * averageRepoMB * fetchDeltaRatio / 1000
+	searches := e.searchesPerSecond()
+
+	add := func(service string, iops, mbps float64) {
+		v := VolumePerformance{Service: service, IOPS: math.Ceil(iops), ThroughputMBps: math.Ceil(mbps)}
+		v.Class, v.StorageClass = volumeClass(v.IOPS, v.ThroughputMBps)
+		p.Volumes = append(p.Volumes, v)
+	}
+	if gitserver, ok := e.Services["gitserver"]; ok {
+		replicas := math.Max(float64(gitserver.Replicas), 1)
+		iops := (p.FetchesPerSecond*iopsPerFetch + searches*gitserverIOPSPerSearch) / replicas
+		mbps := p.FetchesPerSecond * averageRepoMB * (fetchDeltaRatio + fetchArchiveRatio) / replicas
+		add("gitserver", iops, mbps)
+	}
+	if zoekt, ok := e.Services["indexedSearch"]; ok {
+		// Reindexing writes the index again.
+		mbps := e.IndexedSearch.IndexSize * 1000 * zoektDailyReindexRatio / (24 * 60 * 60) / math.Max(float64(zoekt.Replicas), 1)
+		add("indexedSearch", zoektBaseIOPS, mbps)
+	}
+	if _, ok := e.Services["pgsql"]; ok {
+		iops := pgsqlBaseIOPS + float64(e.EngagedUsers)*pgsqlIOPSPerEngagedUser + float64(e.AverageRepositories)*pgsqlIOPSPerRepository
+		add("pgsql", iops, iops*pgsqlPageKB/1000)
+	}
+	e.Storage = p
+}
@@ -0,0 +1,115 @@
+package scaling
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TerraformVariables are the values written to a .tfvars file for provisioning the hosts or
+// node pools of a deployment.
+type TerraformVariables struct {
+	DeploymentType string `json:"deployment_type"`
+
+	// Machine image and docker-compose deployments run on a single instance.
+	InstanceSize     string `json:"instance_size,omitempty"`
+	InstanceVCPUs    int    `json:"instance_vcpus,omitempty"`
+	InstanceMemoryGB int    `json:"instance_memory_gb,omitempty"`
+	DataDiskSizeGB   int    `json:"data_disk_size_gb,omitempty"`
+	// Cheapest instance and data disk type per cloud provider, keyed by lowercase provider name.
+	InstanceTypes map[string]string `json:"instance_types,omitempty"`
+	DataDiskTypes map[string]string `json:"data_disk_types,omitempty"`
+
+	// Kubernetes deployments run on a node pool with one persistent volume per service replica.
+	NodeCount    int            `json:"node_count,omitempty"`
+	NodeVCPUs    int            `json:"node_vcpus,omitempty"`
+	NodeMemoryGB int            `json:"node_memory_gb,omitempty"`
+	PVCSizesGB   map[string]int `json:"pvc_sizes_gb,omitempty"`
+	// Recommended storage class of the gitserver, indexed-search and pgsql volumes.
+	PVCStorageClasses map[string]string `json:"pvc_storage_classes,omitempty"`
+}
+
+func (e *Estimate) terraformVariables() TerraformVariables {
+	if e.DeploymentType != "kubernetes" {
+		v := TerraformVariables{
+			DeploymentType:   e.DeploymentType,
+			InstanceSize:     e.InstanceSize,
+			InstanceVCPUs:    e.TotalCPU,
+			InstanceMemoryGB: e.TotalMemoryGB,
+			DataDiskSizeGB:   e.TotalStorageSize,
+			InstanceTypes:    map[string]string{},
+			DataDiskTypes:    map[string]string{},
+		}
+		for _, r := range e.InstanceRecommendations() {
+			if r.Instance.Name != "" {
+				v.InstanceTypes[strings.ToLower(r.Provider)] = r.Instance.Name
+			}
+			v.DataDiskTypes[strings.ToLower(r.Provider)] = r.Disk.Name
+		}
+		return v
+	}
+	pool := e.NodePool()
+	v := TerraformVariables{
+		DeploymentType:    e.DeploymentType,
+		NodeCount:         pool.Nodes,
+		NodeVCPUs:         int(pool.Shape.CPU),
+		NodeMemoryGB:      int(pool.Shape.MEM),
+		PVCSizesGB:        map[string]int{},
+		PVCStorageClasses: e.Storage.StorageClasses(),
+	}
+	for name, service := range e.Services {
+		if service.Storage > 0 {
+			v.PVCSizesGB[name] = int(service.Storage)
+		}
+	}
+	return v
+}
+
+// TerraformExport returns the estimate as a Terraform variable definitions (.tfvars) file.
+func (e *Estimate) TerraformExport() string {
+	v := e.terraformVariables()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "deployment_type = %q\n", v.DeploymentType)
+	if v.InstanceSize != "" {
+		fmt.Fprintf(&buf, "instance_size = %q\n", v.InstanceSize)
+		fmt.Fprintf(&buf, "instance_vcpus = %v\n", v.InstanceVCPUs)
+		fmt.Fprintf(&buf, "instance_memory_gb = %v\n", v.InstanceMemoryGB)
+		fmt.Fprintf(&buf, "data_disk_size_gb = %v\n", v.DataDiskSizeGB)
+		writeHCLMap(&buf, "instance_types", v.InstanceTypes)
+		writeHCLMap(&buf, "data_disk_types", v.DataDiskTypes)
+	}
+	if v.NodeCount > 0 {
+		fmt.Fprintf(&buf, "node_count = %v\n", v.NodeCount)
+		fmt.Fprintf(&buf, "node_vcpus = %v\n", v.NodeVCPUs)
+		fmt.Fprintf(&buf, "node_memory_gb = %v\n", v.NodeMemoryGB)
+		writeHCLMap(&buf, "pvc_sizes_gb", v.PVCSizesGB)
+		writeHCLMap(&buf, "pvc_storage_classes", v.PVCStorageClasses)
+	}
+	return buf.String()
+}
+
+// writeHCLMap writes a map variable with sorted keys.
+func writeHCLMap[V any](buf *bytes.Buffer, name string, m map[string]V) {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(buf, "%v = {\n", name)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "  %q = %#v\n", k, m[k])
+	}
+	fmt.Fprintf(buf, "}\n")
+}
+
+// TerraformJSONExport returns the estimate as a JSON Terraform variable definitions
+// (.tfvars.json) file.
+func (e *Estimate) TerraformJSONExport() string {
+	j, err := json.MarshalIndent(e.terraformVariables(), "", "  ")
+	if err != nil {
+		fmt.Printf("err: %v\n", err)
+	}
+	return string(j) + "\n"
+}
@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/hexops/vecty"
 	"github.com/hexops/vecty/elem"
 	"github.com/hexops/vecty/event"
 	"github.com/hexops/vecty/prop"
+	"github.com/sourcegraph/resource-estimator/internal/inventory"
 	"github.com/sourcegraph/resource-estimator/internal/scaling"
 
 	"github.com/microcosm-cc/bluemonday"
@@ -19,13 +21,33 @@ func main() {
 	err := vecty.RenderInto("#root", &MainView{
 		deploymentType:    "type",
 		users:             300,      // Number of users
-		engagementRate:    100,      // TODO: Remove
+		engagementRate:    100,      // Percentage of users who use Sourcegraph regularly
 		repositories:      3000,     // Number of repos
 		reposize:          100,      //Total repo size
 		largeMonorepos:    0,        // TODO: Remove
 		largestRepoSize:   5,        // Size of the largest repo
 		largestIndexSize:  1,        // Size of the largest index file
 		codeinsightEabled: "Enable", // Code Insight
+		highAvailability:  "Disable",
+		peakConcurrency:   scaling.DefaultPeakConcurrency,
+		automationHeavy:   "No",
+		scipLanguages:     1,
+		scipRetentionDays: scaling.DefaultSCIPRetentionDays,
+		insightsRetention: scaling.DefaultInsightsRetentionMonths,
+
+		prometheusRetentionDays: scaling.DefaultPrometheusRetentionDays,
+		scrapeIntervalSeconds:   scaling.DefaultScrapeIntervalSeconds,
+		observabilityMode:       scaling.ObservabilityBundled,
+		cloneThroughput:         scaling.DefaultCloneThroughputMBps,
+		diskThroughput:          scaling.DefaultDiskThroughputMBps,
+		repoFetchInterval:       scaling.DefaultRepoFetchIntervalMinutes,
+		backupFrequency:         scaling.DefaultBackupFrequencyHours,
+		backupRetention:         scaling.DefaultBackupRetentionCount,
+		warmStandby:             scaling.StandbyNone,
+		stagingRepositories:     scaling.DefaultStagingRepositoryPercent,
+		stagingUsers:            scaling.DefaultStagingUserPercent,
+		exportEnvironment:       "production",
+		quotaHeadroom:           scaling.DefaultQuotaHeadroomPercent,
 	})
 	if err != nil {
 		panic(err)
@@ -39,7 +61,21 @@ func main() {
 type MainView struct {
 	vecty.Core
 	repositories, largeMonorepos, users, engagementRate, reposize, largestRepoSize, largestIndexSize int
-	deploymentType, codeinsightEabled                                                                string
+	gitserverMaxVolumeSize, peakConcurrency, peakSearchQPS, scipRetentionDays, insightsRetention     int
+	insightSeries, reposPerSeries, scipUploadsPerDay, averageIndexSize, scipLanguages                int
+	deploymentType, codeinsightEabled, highAvailability, topRepositories, automationHeavy            string
+	repositoryCode                                                                                   string
+	usageStatistics, usageStatisticsError                                                            string
+	prometheusRetentionDays, scrapeIntervalSeconds, activeSeries, traceSamplingRate                  int
+	observabilityMode                                                                                string
+	cloneThroughput, diskThroughput, repoFetchInterval                                               int
+	backupFrequency, backupRetention                                                                 int
+	warmStandby                                                                                      string
+	stagingRepositories, stagingUsers                                                                int
+	exportEnvironment                                                                                string
+	quotaHeadroom                                                                                    int
+	// Growth over the planning horizon, which is disabled while the horizon is 0.
+	horizonMonths, reposPerMonth, usersPerMonth, repoSizeGrowth, indexGrowth int
 }
 
 func (p *MainView) numberInput(postLabel string, handler func(e *vecty.Event), value int, rnge scaling.Range, step int) vecty.ComponentOrHTML {
@@ -74,7 +110,25 @@ func (p *MainView) numberInput(postLabel string, handler func(e *vecty.Event), v
 	)
 }
 
-func (p *MainView) radioInput(groupName string, options []string, handler func(e *vecty.Event)) vecty.ComponentOrHTML {
+func (p *MainView) textInput(postLabel string, handler func(e *vecty.Event), value string) vecty.ComponentOrHTML {
+	return elem.Label(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Input(
+			vecty.Markup(
+				vecty.Style("width", "30%"),
+				event.Input(handler),
+				vecty.Property("type", "text"),
+				vecty.Property("value", value),
+			),
+		),
+		elem.Div(
+			vecty.Markup(vecty.Class("post-label")),
+			vecty.Text(postLabel),
+		),
+	)
+}
+
+func (p *MainView) radioInput(groupName string, options []string, selected string, handler func(e *vecty.Event)) vecty.ComponentOrHTML {
 	var list vecty.List
 	for i, option := range options {
 		list = append(list, elem.Label(
@@ -86,6 +140,7 @@ func (p *MainView) radioInput(groupName string, options []string, handler func(e
 					vecty.Property("name", groupName),
 					vecty.MarkupIf(i == 0, vecty.Property("defaultChecked", "yes")),                 // pre-check the first option on every radio input
 					vecty.MarkupIf(option == "kubernetes", vecty.Property("defaultChecked", "yes")), // start the estimator with kubernetes
+					vecty.MarkupIf(option == selected, vecty.Property("checked", true)),
 				),
 			),
 			elem.Span(vecty.Text(option)),
@@ -101,13 +156,64 @@ func (p *MainView) radioInput(groupName string, options []string, handler func(e
 	)
 }
 
+// prefill fills the form from the usage statistics of an existing instance.
+func (p *MainView) prefill(usageStatistics string) {
+	p.usageStatistics = usageStatistics
+	p.usageStatisticsError = ""
+	if usageStatistics == "" {
+		return
+	}
+	input, err := inventory.ImportUsage([]byte(usageStatistics))
+	if err != nil {
+		p.usageStatisticsError = fmt.Sprint("- ", err)
+		return
+	}
+	if input.Users > 0 {
+		p.users = input.Users
+	}
+	if input.EngagementRate > 0 {
+		p.engagementRate = input.EngagementRate
+	}
+	if input.Repositories > 0 {
+		p.repositories = input.Repositories
+	}
+	if input.TotalRepoSize > 0 {
+		p.reposize = input.TotalRepoSize
+	}
+	if input.CodeInsight != "" {
+		p.codeinsightEabled = input.CodeInsight
+	}
+	if input.LargestIndexSize > 0 && p.largestIndexSize == 0 {
+		p.largestIndexSize = input.LargestIndexSize
+	}
+}
+
 func (p *MainView) inputs() vecty.ComponentOrHTML {
 	return vecty.List{
+		elem.Details(
+			vecty.Markup(vecty.Style("margin-bottom", "10px")),
+			elem.Summary(vecty.Text("Prefill from the usage statistics of an existing instance")),
+			elem.TextArea(
+				vecty.Markup(
+					vecty.Class("copy-as-markdown"),
+					vecty.Property("placeholder", "Paste the JSON from Site admin > Pings"),
+					event.Input(func(e *vecty.Event) {
+						p.prefill(e.Value.Get("target").Get("value").String())
+						vecty.Rerender(p)
+					}),
+				),
+				vecty.Text(p.usageStatistics),
+			),
+			elem.Div(
+				vecty.Markup(vecty.Class("errorInput")),
+				vecty.Text(p.usageStatisticsError),
+			),
+		),
 		elem.Div(
 			vecty.Markup(
 				vecty.Style("padding", "20px"),
 				vecty.Style("border", "1px solid")),
-			p.radioInput("Deployment Type: ", []string{"docker-compose", "kubernetes"}, func(e *vecty.Event) {
+			p.radioInput("Deployment Type: ", []string{"docker-compose", "kubernetes"}, p.deploymentType, func(e *vecty.Event) {
 				p.deploymentType = e.Value.Get("target").Get("value").String()
 				vecty.Rerender(p)
 			}),
@@ -115,6 +221,22 @@ func (p *MainView) inputs() vecty.ComponentOrHTML {
 				p.users, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
 				vecty.Rerender(p)
 			}, p.users, scaling.UsersRange, 1),
+			p.numberInput("% - users who use Sourcegraph regularly (engagement rate)", func(e *vecty.Event) {
+				p.engagementRate, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.engagementRate, scaling.EngagementRateRange, 1),
+			p.numberInput("% - engaged users active at the same time at peak", func(e *vecty.Event) {
+				p.peakConcurrency, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.peakConcurrency, scaling.PeakConcurrencyRange, 1),
+			p.numberInput("QPS - expected search queries per second at peak (0 to size by users)", func(e *vecty.Event) {
+				p.peakSearchQPS, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.peakSearchQPS, scaling.SearchQPSRange, 1),
+			p.radioInput("Automation Heavy: ", []string{"No", "Yes"}, p.automationHeavy, func(e *vecty.Event) {
+				p.automationHeavy = e.Value.Get("target").Get("value").String()
+				vecty.Rerender(p)
+			}),
 			p.numberInput("repositories", func(e *vecty.Event) {
 				p.repositories, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
 				vecty.Rerender(p)
@@ -127,6 +249,14 @@ func (p *MainView) inputs() vecty.ComponentOrHTML {
 				p.largestRepoSize, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
 				vecty.Rerender(p)
 			}, p.largestRepoSize, scaling.LargestRepoSizeRange, 1),
+			p.textInput("GB - sizes of the largest repositories, comma separated (optional)", func(e *vecty.Event) {
+				p.topRepositories = e.Value.Get("target").Get("value").String()
+				vecty.Rerender(p)
+			}, p.topRepositories),
+			p.numberInput("GB - maximum volume size per gitserver shard (0 for no limit)", func(e *vecty.Event) {
+				p.gitserverMaxVolumeSize, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.gitserverMaxVolumeSize, scaling.MaxVolumeSizeRange, 1),
 			p.numberInput("GB - size of the largest SCIP index file", func(e *vecty.Event) {
 				p.largestIndexSize, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
 				vecty.Rerender(p)
@@ -135,16 +265,177 @@ func (p *MainView) inputs() vecty.ComponentOrHTML {
 				vecty.Markup(vecty.Style("margin-top", "5px"), vecty.Style("font-size", "small")),
 				vecty.Text("Note: Set the value above to 0 to disable Precise Code Intelligence."),
 			),
-			p.radioInput("Code Insights: ", []string{"Enable", "Disable"}, func(e *vecty.Event) {
+			p.textInput("lines of code and language of the largest repositories to predict the SCIP index size, comma separated, e.g. 2000000 go, 500000 java (optional)", func(e *vecty.Event) {
+				p.repositoryCode = e.Value.Get("target").Get("value").String()
+				vecty.Rerender(p)
+			}, p.repositoryCode),
+			p.numberInput("commits indexed a day (0 to assume one upload of the largest index)", func(e *vecty.Event) {
+				p.scipUploadsPerDay, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.scipUploadsPerDay, scaling.SCIPUploadsPerDayRange, 1),
+			p.numberInput("MB - average size of a SCIP index file (0 for the largest size)", func(e *vecty.Event) {
+				p.averageIndexSize, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.averageIndexSize, scaling.AverageIndexSizeRange, 1),
+			p.numberInput("languages indexed, each uploaded separately", func(e *vecty.Event) {
+				p.scipLanguages, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.scipLanguages, scaling.SCIPLanguagesRange, 1),
+			p.numberInput("days - SCIP uploads are kept, other than the tip of the default branch", func(e *vecty.Event) {
+				p.scipRetentionDays, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.scipRetentionDays, scaling.SCIPRetentionDaysRange, 1),
+			p.radioInput("Code Insights: ", []string{"Enable", "Disable"}, p.codeinsightEabled, func(e *vecty.Event) {
 				p.codeinsightEabled = e.Value.Get("target").Get("value").String()
 				vecty.Rerender(p)
 			}),
+			p.numberInput("code insights series (0 for the default)", func(e *vecty.Event) {
+				p.insightSeries, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.insightSeries, scaling.InsightSeriesRange, 1),
+			p.numberInput("repositories per code insights series on average (0 for all of them)", func(e *vecty.Event) {
+				p.reposPerSeries, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.reposPerSeries, scaling.ReposPerSeriesRange, 1),
+			p.numberInput("months - code insights data points are kept", func(e *vecty.Event) {
+				p.insightsRetention, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.insightsRetention, scaling.InsightsRetentionMonthsRange, 1),
+			p.radioInput("High Availability: ", []string{"Disable", "Enable"}, p.highAvailability, func(e *vecty.Event) {
+				p.highAvailability = e.Value.Get("target").Get("value").String()
+				vecty.Rerender(p)
+			}),
 		),
 	}
 }
 
+func (p *MainView) observabilityInputs() vecty.ComponentOrHTML {
+	return elem.Details(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Summary(vecty.Text("Observability")),
+		p.radioInput("Monitoring and tracing: ", []string{scaling.ObservabilityBundled, scaling.ObservabilityExternal, scaling.ObservabilityDisabled}, p.observabilityMode, func(e *vecty.Event) {
+			p.observabilityMode = e.Value.Get("target").Get("value").String()
+			vecty.Rerender(p)
+		}),
+		p.numberInput("days - Prometheus metrics are kept", func(e *vecty.Event) {
+			p.prometheusRetentionDays, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.prometheusRetentionDays, scaling.PrometheusRetentionDaysRange, 1),
+		p.numberInput("seconds - Prometheus scrape interval", func(e *vecty.Event) {
+			p.scrapeIntervalSeconds, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.scrapeIntervalSeconds, scaling.ScrapeIntervalSecondsRange, 1),
+		p.numberInput("active Prometheus series (0 to estimate them from the replicas)", func(e *vecty.Event) {
+			p.activeSeries, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.activeSeries, scaling.ActiveSeriesRange, 1),
+		p.numberInput("% - requests traced (0 for only the requests which ask for it)", func(e *vecty.Event) {
+			p.traceSamplingRate, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.traceSamplingRate, scaling.TraceSamplingRateRange, 1),
+	)
+}
+
+func (p *MainView) rolloutInputs() vecty.ComponentOrHTML {
+	return elem.Details(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Summary(vecty.Text("Initial rollout and fetches")),
+		p.numberInput("MB/s - network throughput from the code hosts to each gitserver replica", func(e *vecty.Event) {
+			p.cloneThroughput, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.cloneThroughput, scaling.CloneThroughputRange, 1),
+		p.numberInput("MB/s - write throughput of a gitserver volume", func(e *vecty.Event) {
+			p.diskThroughput, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.diskThroughput, scaling.DiskThroughputRange, 1),
+		p.numberInput("minutes - average interval at which repositories are fetched from the code hosts", func(e *vecty.Event) {
+			p.repoFetchInterval, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.repoFetchInterval, scaling.RepoFetchIntervalRange, 1),
+	)
+}
+
+func (p *MainView) backupInputs() vecty.ComponentOrHTML {
+	return elem.Details(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Summary(vecty.Text("Backup and disaster recovery")),
+		p.numberInput("hours - interval between snapshots", func(e *vecty.Event) {
+			p.backupFrequency, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.backupFrequency, scaling.BackupFrequencyHoursRange, 1),
+		p.numberInput("snapshots kept", func(e *vecty.Event) {
+			p.backupRetention, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.backupRetention, scaling.BackupRetentionCountRange, 1),
+		p.radioInput("Warm standby region: ", []string{scaling.StandbyNone, scaling.StandbyFull, scaling.StandbyReduced}, p.warmStandby, func(e *vecty.Event) {
+			p.warmStandby = e.Value.Get("target").Get("value").String()
+			vecty.Rerender(p)
+		}),
+	)
+}
+
+func (p *MainView) stagingInputs() vecty.ComponentOrHTML {
+	return elem.Details(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Summary(vecty.Text("Staging environment")),
+		p.numberInput("% - share of the repositories mirrored to staging", func(e *vecty.Event) {
+			p.stagingRepositories, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.stagingRepositories, scaling.StagingRepositoryPercentRange, 1),
+		p.numberInput("% - share of the users who use staging", func(e *vecty.Event) {
+			p.stagingUsers, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.stagingUsers, scaling.StagingUserPercentRange, 1),
+	)
+}
+
+func (p *MainView) growthInputs() vecty.ComponentOrHTML {
+	return elem.Details(
+		vecty.Markup(vecty.Style("margin-top", "10px")),
+		elem.Summary(vecty.Text("Growth projection")),
+		p.numberInput("months - planning horizon (0 to disable)", func(e *vecty.Event) {
+			p.horizonMonths, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.horizonMonths, scaling.HorizonMonthsRange, 1),
+		p.numberInput("new repositories per month", func(e *vecty.Event) {
+			p.reposPerMonth, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.reposPerMonth, scaling.RepositoriesRange, 1),
+		p.numberInput("% - repository size growth per month", func(e *vecty.Event) {
+			p.repoSizeGrowth, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.repoSizeGrowth, scaling.GrowthRateRange, 1),
+		p.numberInput("new users per month", func(e *vecty.Event) {
+			p.usersPerMonth, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.usersPerMonth, scaling.UsersRange, 1),
+		p.numberInput("% - SCIP index size growth per month", func(e *vecty.Event) {
+			p.indexGrowth, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+			vecty.Rerender(p)
+		}, p.indexGrowth, scaling.GrowthRateRange, 1),
+	)
+}
+
 // Render implements the vecty.Component interface.
 func (p *MainView) Render() vecty.ComponentOrHTML {
+	var distribution *scaling.RepoSizeDistribution
+	if sizes := parseSizes(p.topRepositories); len(sizes) > 0 {
+		distribution = &scaling.RepoSizeDistribution{TopRepositories: sizes}
+	}
+	var repositoryCode []scaling.RepositoryCode
+	if p.largestIndexSize > 0 {
+		repositoryCode = parseRepositoryCode(p.repositoryCode)
+	}
+	var growth *scaling.Growth
+	if p.horizonMonths > 0 {
+		growth = &scaling.Growth{
+			RepositoriesPerMonth: p.reposPerMonth,
+			RepoSizeGrowth:       float64(p.repoSizeGrowth),
+			UsersPerMonth:        p.usersPerMonth,
+			IndexGrowth:          float64(p.indexGrowth),
+			HorizonMonths:        p.horizonMonths,
+		}
+	}
 	estimate := (&scaling.Estimate{
 		DeploymentType:   p.deploymentType,
 		Repositories:     p.repositories,
@@ -154,17 +445,67 @@ func (p *MainView) Render() vecty.ComponentOrHTML {
 		LargestIndexSize: p.largestIndexSize,
 		Users:            p.users,
 		EngagementRate:   p.engagementRate,
+		PeakConcurrency:  p.peakConcurrency,
+		PeakSearchQPS:    p.peakSearchQPS,
+		AutomationHeavy:  p.automationHeavy == "Yes",
 		CodeInsight:      p.codeinsightEabled,
+		HighAvailability: p.highAvailability == "Enable",
+
+		GitserverMaxVolumeSize:   p.gitserverMaxVolumeSize,
+		RepoSizeDistribution:     distribution,
+		Growth:                   growth,
+		IndexSizeRepositories:    repositoryCode,
+		PrometheusRetentionDays:  p.prometheusRetentionDays,
+		ScrapeIntervalSeconds:    p.scrapeIntervalSeconds,
+		ActiveSeries:             p.activeSeries,
+		ObservabilityMode:        p.observabilityMode,
+		TraceSamplingRate:        p.traceSamplingRate,
+		CloneThroughputMBps:      p.cloneThroughput,
+		DiskThroughputMBps:       p.diskThroughput,
+		RepoFetchIntervalMinutes: p.repoFetchInterval,
+		BackupFrequencyHours:     p.backupFrequency,
+		BackupRetentionCount:     p.backupRetention,
+		WarmStandby:              p.warmStandby,
+		StagingRepositoryPercent: p.stagingRepositories,
+		StagingUserPercent:       p.stagingUsers,
+		QuotaHeadroomPercent:     p.quotaHeadroom,
+		SCIPUploadsPerDay:        p.scipUploadsPerDay,
+		AverageIndexSizeMB:       p.averageIndexSize,
+		SCIPLanguages:            p.scipLanguages,
+		SCIPRetentionDays:        p.scipRetentionDays,
+		InsightSeries:            p.insightSeries,
+		ReposPerSeries:           p.reposPerSeries,
+		InsightsRetentionMonths:  p.insightsRetention,
 	}).Calculate()
 
 	markdownContent := estimate.MarkdownExport()
-	helmContent := estimate.HelmExport()
+	// The exports are of the production estimate, or of the staging environment derived from it.
+	exported := estimate
+	if p.exportEnvironment == "staging" {
+		exported = estimate.Staging()
+	}
+	exportedMarkdown := exported.MarkdownExport()
+	helmContent := exported.HelmExport()
+	terraformContent := exported.TerraformExport()
+	terraformJSONContent := exported.TerraformJSONExport()
+	hpaContent := exported.HPAExport()
+	pdbContent := exported.PDBExport()
+	quotaContent := exported.QuotaExport()
 
 	return elem.Form(
 		vecty.Markup(vecty.Class("estimator")),
 		p.inputs(),
+		p.observabilityInputs(),
+		p.rolloutInputs(),
+		p.backupInputs(),
+		p.stagingInputs(),
+		p.growthInputs(),
 		&markdown{Content: markdownContent},
 		elem.Heading3(vecty.Text("Export result")),
+		p.radioInput("Environment: ", []string{"production", "staging"}, p.exportEnvironment, func(e *vecty.Event) {
+			p.exportEnvironment = e.Value.Get("target").Get("value").String()
+			vecty.Rerender(p)
+		}),
 		elem.Details(
 			elem.Summary(
 				elem.Span(
@@ -190,18 +531,135 @@ func (p *MainView) Render() vecty.ComponentOrHTML {
 				),
 			),
 		),
+		vecty.If(exported.DeploymentType == "kubernetes" && hpaContent != "", elem.Details(
+			elem.Summary(vecty.Text("Export as Horizontal Pod Autoscalers")),
+			elem.Break(),
+			elem.TextArea(
+				vecty.Markup(vecty.Class("copy-as-markdown")),
+				vecty.Text(hpaContent),
+			),
+			elem.Paragraph(
+				elem.Strong(vecty.Text("Click to Download: ")),
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Markup(prop.Href("data:text/plain;charset=utf-8,"+hpaContent)),
+						vecty.Property("download", "hpa.yaml"),
+					),
+					vecty.Text("hpa.yaml"),
+				),
+			),
+		)),
+		vecty.If(exported.DeploymentType == "kubernetes" && exported.HighAvailability, elem.Details(
+			elem.Summary(vecty.Text("Export as Pod Disruption Budgets")),
+			elem.Break(),
+			elem.TextArea(
+				vecty.Markup(vecty.Class("copy-as-markdown")),
+				vecty.Text(pdbContent),
+			),
+			elem.Paragraph(
+				elem.Strong(vecty.Text("Click to Download: ")),
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Markup(prop.Href("data:text/plain;charset=utf-8,"+pdbContent)),
+						vecty.Property("download", "pdb.yaml"),
+					),
+					vecty.Text("pdb.yaml"),
+				),
+			),
+		)),
+		vecty.If(exported.DeploymentType == "kubernetes", elem.Details(
+			elem.Summary(vecty.Text("Export as Namespace ResourceQuota and LimitRange")),
+			p.numberInput("% - room the quota leaves for the surge pods of rolling updates", func(e *vecty.Event) {
+				p.quotaHeadroom, _ = strconv.Atoi(e.Value.Get("target").Get("value").String())
+				vecty.Rerender(p)
+			}, p.quotaHeadroom, scaling.QuotaHeadroomRange, 1),
+			elem.Break(),
+			elem.TextArea(
+				vecty.Markup(vecty.Class("copy-as-markdown")),
+				vecty.Text(quotaContent),
+			),
+			elem.Paragraph(
+				elem.Strong(vecty.Text("Click to Download: ")),
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Markup(prop.Href("data:text/plain;charset=utf-8,"+quotaContent)),
+						vecty.Property("download", "quota.yaml"),
+					),
+					vecty.Text("quota.yaml"),
+				),
+			),
+		)),
+		elem.Details(
+			elem.Summary(vecty.Text("Export as Terraform Variables")),
+			elem.Break(),
+			elem.TextArea(
+				vecty.Markup(vecty.Class("copy-as-markdown")),
+				vecty.Text(terraformContent),
+			),
+			elem.Paragraph(
+				elem.Strong(vecty.Text("Click to Download: ")),
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Markup(prop.Href("data:text/plain;charset=utf-8,"+terraformContent)),
+						vecty.Property("download", "sourcegraph.tfvars"),
+					),
+					vecty.Text("sourcegraph.tfvars"),
+				),
+				vecty.Text(" "),
+				elem.Anchor(
+					vecty.Markup(
+						vecty.Markup(prop.Href("data:application/json;charset=utf-8,"+terraformJSONContent)),
+						vecty.Property("download", "sourcegraph.tfvars.json"),
+					),
+					vecty.Text("sourcegraph.tfvars.json"),
+				),
+			),
+		),
 		elem.Details(
 			elem.Summary(vecty.Text("Export as Markdown")),
 			elem.Break(),
 			elem.TextArea(
 				vecty.Markup(vecty.Class("copy-as-markdown")),
-				vecty.Text(string(markdownContent)),
+				vecty.Text(string(exportedMarkdown)),
 			),
 		),
 		elem.Break(),
 	)
 }
 
+// parseSizes parses a comma separated list of sizes, ignoring anything that is not a number.
+func parseSizes(s string) []float64 {
+	var sizes []float64
+	for _, field := range strings.Split(s, ",") {
+		if size, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil && size > 0 {
+			sizes = append(sizes, size)
+		}
+	}
+	return sizes
+}
+
+// parseRepositoryCode parses a comma separated list of lines of code, each followed by the language
+// of the repository.
+func parseRepositoryCode(s string) []scaling.RepositoryCode {
+	var repos []scaling.RepositoryCode
+	for _, field := range strings.Split(s, ",") {
+		parts := strings.Fields(field)
+		if len(parts) == 0 {
+			continue
+		}
+		loc, err := strconv.Atoi(parts[0])
+		if err != nil || loc <= 0 {
+			continue
+		}
+		repo := scaling.RepositoryCode{LinesOfCode: loc}
+		if len(parts) > 1 {
+			repo.Language = strings.Join(parts[1:], " ")
+		}
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
 // markdown is a simple component which renders the Input markdown as sanitized
 // HTML into a div.
 type markdown struct {